@@ -4,6 +4,16 @@
 // @Desc
 package wxhelper_sdk
 
+import (
+	"encoding/json"
+	"fmt"
+	"wxhelper-sdk/inner/models"
+)
+
+const redacted = "******"
+
+// Account 当前登录的微信账号
+// DbKey 与 PrivateKey 属于敏感信息，序列化为 JSON（包括日志输出）与 String 时默认脱敏，需要时请直接读取字段
 type Account struct {
 	Account         string `json:"account"`
 	City            string `json:"city"`
@@ -20,3 +30,46 @@ type Account struct {
 	PrivateKey      string `json:"privateKey"`
 	PublicKey       string `json:"publicKey"`
 }
+
+func newAccount(a *models.Account) *Account {
+	if a == nil {
+		return nil
+	}
+	return &Account{
+		Account:         a.Account,
+		City:            a.City,
+		Country:         a.Country,
+		CurrentDataPath: a.CurrentDataPath,
+		DataSavePath:    a.DataSavePath,
+		DbKey:           a.DbKey,
+		HeadImage:       a.HeadImage,
+		Mobile:          a.Mobile,
+		Name:            a.Name,
+		Province:        a.Province,
+		Signature:       a.Signature,
+		Wxid:            a.Wxid,
+		PrivateKey:      a.PrivateKey,
+		PublicKey:       a.PublicKey,
+	}
+}
+
+// Redacted 返回敏感字段已脱敏的副本
+func (a Account) Redacted() Account {
+	if a.DbKey != "" {
+		a.DbKey = redacted
+	}
+	if a.PrivateKey != "" {
+		a.PrivateKey = redacted
+	}
+	return a
+}
+
+// MarshalJSON 序列化时脱敏
+func (a Account) MarshalJSON() ([]byte, error) {
+	type account Account // 避免递归调用 MarshalJSON
+	return json.Marshal(account(a.Redacted()))
+}
+
+func (a Account) String() string {
+	return fmt.Sprintf("Account{wxid: %s, account: %s, name: %s}", a.Wxid, a.Account, a.Name)
+}
@@ -0,0 +1,20 @@
+package wxhelper_sdk
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestAccount_Redacted(t *testing.T) {
+	a := &Account{Wxid: "wxid_bot", Name: "bot", DbKey: "secret-db-key", PrivateKey: "secret-private-key", PublicKey: "pub"}
+	data, err := json.Marshal(a)
+	assert.Nil(t, err)
+	assert.False(t, strings.Contains(string(data), "secret"))
+	assert.True(t, strings.Contains(string(data), `"publicKey":"pub"`))
+	assert.False(t, strings.Contains(a.String(), "secret"))
+
+	// 原始字段不受影响
+	assert.Equal(t, "secret-db-key", a.DbKey)
+}
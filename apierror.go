@@ -0,0 +1,24 @@
+// Package wxhelper_sdk
+// @Author Clover
+// @Data 2025/1/18 上午11:00:00
+// @Desc wxhelper 接口错误
+package wxhelper_sdk
+
+import "wxhelper-sdk/inner"
+
+// APIError 请求 wxhelper 接口失败，包含接口路径、http 状态码及 result 的 code、msg
+//
+//	var apiErr *APIError
+//	if errors.As(err, &apiErr) && apiErr.Code == -1 { ... }
+type APIError = inner.APIError
+
+// APICall 一次 wxhelper 接口调用的信息（接口路径、状态码、返回码、耗时、错误），见 WithAPIObserver
+type APICall = inner.CallInfo
+
+var (
+	ErrAPI              = inner.ErrAPI              // 所有 APIError 均匹配
+	ErrRequest          = inner.ErrRequest          // 网络错误、超时等，未得到响应
+	ErrUnexpectedStatus = inner.ErrUnexpectedStatus // http 状态码非 2xx
+	ErrUnexpectedCode   = inner.ErrUnexpectedCode   // result.Code 不是该接口的成功码
+	ErrDecodeResponse   = inner.ErrJSONDecoder      // 响应无法解析
+)
@@ -0,0 +1,91 @@
+package wxhelper_sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"wxhelper-sdk/inner"
+)
+
+func TestAPIError(t *testing.T) {
+	var status, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status == "500" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+	client := NewClient(WithAPIBaseURL(server.URL), WithCacheDir(t.TempDir()))
+	defer client.stop()
+	client.state.Store(int32(StateLoggedIn))
+	ctx := context.Background()
+
+	// 返回码不符合接口的成功码
+	body = `{"code":-1,"msg":"not friend"}`
+	err := client.SendText(ctx, "wxid_a", "hi")
+	var apiErr *APIError
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, inner.EndpointSendText, apiErr.Endpoint)
+		assert.Equal(t, http.StatusOK, apiErr.StatusCode)
+		assert.Equal(t, -1, apiErr.Code)
+		assert.Equal(t, "not friend", apiErr.Msg)
+	}
+	assert.True(t, errors.Is(err, ErrSendFailed))
+	assert.True(t, errors.Is(err, ErrAPI))
+	assert.True(t, errors.Is(err, ErrUnexpectedCode))
+
+	// 不同接口的成功码不同
+	body = `{"code":1,"msg":"success"}`
+	assert.Nil(t, client.SendImage(ctx, "wxid_a", `C:\a.png`))
+	assert.True(t, errors.Is(client.SendText(ctx, "wxid_a", "hi"), ErrUnexpectedCode))
+
+	status = "500"
+	err = client.SendText(ctx, "wxid_a", "hi")
+	assert.True(t, errors.Is(err, ErrUnexpectedStatus))
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+	}
+
+	status, body = "", "not json"
+	assert.True(t, errors.Is(client.SendText(ctx, "wxid_a", "hi"), ErrDecodeResponse))
+
+	server.Close()
+	err = client.SendText(ctx, "wxid_a", "hi")
+	assert.True(t, errors.Is(err, ErrRequest))
+	assert.False(t, errors.Is(err, ErrUnexpectedCode))
+}
+
+func TestAPIObserver(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		_, _ = w.Write([]byte(`{"code":0,"msg":"success"}`))
+	}))
+	defer server.Close()
+	var calls []APICall
+	client := NewClient(WithAPIBaseURL(server.URL), WithCacheDir(t.TempDir()), WithAPIObserver(func(call APICall) {
+		calls = append(calls, call)
+	}))
+	defer client.stop()
+	client.state.Store(int32(StateLoggedIn))
+
+	assert.Nil(t, client.SendText(context.Background(), "wxid_a", "hi"))
+	assert.Equal(t, map[string]any{"wxid": "wxid_a", "msg": "hi"}, body)
+	ok, err := client.wxClient.CheckLogin(context.Background())
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	assert.Nil(t, body) // 无请求体
+
+	if assert.Len(t, calls, 2) {
+		assert.Equal(t, inner.EndpointSendText, calls[0].Endpoint)
+		assert.Equal(t, http.StatusOK, calls[0].StatusCode)
+		assert.Nil(t, calls[0].Err)
+		assert.Equal(t, inner.EndpointCheckLogin, calls[1].Endpoint)
+	}
+}
@@ -0,0 +1,348 @@
+// Package wxhelper_sdk
+// @Author Clover
+// @Data 2025/1/13 下午2:15:00
+// @Desc 应用消息（type 49）<appmsg> 解析
+package wxhelper_sdk
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AppMsgType <appmsg> 中的 <type>
+type AppMsgType int
+
+const (
+	AppMsgTypeText            AppMsgType = 1
+	AppMsgTypeImage           AppMsgType = 2
+	AppMsgTypeAudio           AppMsgType = 3  // 音乐
+	AppMsgTypeVideo           AppMsgType = 4  // 视频链接
+	AppMsgTypeLink            AppMsgType = 5  // 链接分享
+	AppMsgTypeFile            AppMsgType = 6  // 文件
+	AppMsgTypeEmoji           AppMsgType = 8  // 表情
+	AppMsgTypeChatHistory     AppMsgType = 19 // 聊天记录
+	AppMsgTypeMiniProgram     AppMsgType = 33 // 小程序
+	AppMsgTypeMiniProgramPage AppMsgType = 36 // 小程序页面
+	AppMsgTypeQuote           AppMsgType = 57 // 引用回复
+	AppMsgTypeFileUploading   AppMsgType = 74 // 文件上传中
+	AppMsgTypeTransfer        AppMsgType = 2000
+	AppMsgTypeRedPacket       AppMsgType = 2001
+)
+
+// AppMsg 应用消息 <appmsg> 原始结构
+type AppMsg struct {
+	AppID             string     `xml:"appid,attr" json:"appId,omitempty"`
+	Title             string     `xml:"title" json:"title"`
+	Des               string     `xml:"des" json:"des,omitempty"`
+	Type              AppMsgType `xml:"type" json:"type"`
+	URL               string     `xml:"url" json:"url,omitempty"`
+	ThumbURL          string     `xml:"thumburl" json:"thumbUrl,omitempty"`
+	SourceUsername    string     `xml:"sourceusername" json:"sourceUsername,omitempty"`
+	SourceDisplayName string     `xml:"sourcedisplayname" json:"sourceDisplayName,omitempty"`
+	MD5               string     `xml:"md5" json:"md5,omitempty"`
+	RecordItem        string     `xml:"recorditem" json:"-"` // 聊天记录的内层 XML
+	AppAttach         *AppAttach `xml:"appattach" json:"appAttach,omitempty"`
+	WeAppInfo         *WeAppInfo `xml:"weappinfo" json:"weAppInfo,omitempty"`
+	ReferMsg          *ReferMsg  `xml:"refermsg" json:"referMsg,omitempty"`
+	WCPayInfo         *WCPayInfo `xml:"wcpayinfo" json:"wcPayInfo,omitempty"`
+}
+
+// AppAttach 附件信息
+type AppAttach struct {
+	TotalLen     int64  `xml:"totallen" json:"totalLen"`
+	AttachID     string `xml:"attachid" json:"attachId,omitempty"`
+	FileExt      string `xml:"fileext" json:"fileExt,omitempty"`
+	CdnAttachURL string `xml:"cdnattachurl" json:"cdnAttachUrl,omitempty"`
+	AesKey       string `xml:"aeskey" json:"aesKey,omitempty"`
+}
+
+// WeAppInfo 小程序信息
+type WeAppInfo struct {
+	PagePath     string `xml:"pagepath" json:"pagePath,omitempty"`
+	Username     string `xml:"username" json:"username"`
+	AppID        string `xml:"appid" json:"appId"`
+	Version      int    `xml:"version" json:"version,omitempty"`
+	WeAppIconURL string `xml:"weappiconurl" json:"weAppIconUrl,omitempty"`
+}
+
+// ReferMsg 被引用的消息
+type ReferMsg struct {
+	Type        MsgType `xml:"type" json:"type"`
+	SvrID       string  `xml:"svrid" json:"svrId"`
+	FromUser    string  `xml:"fromusr" json:"fromUser"`
+	ChatUser    string  `xml:"chatusr" json:"chatUser,omitempty"`
+	DisplayName string  `xml:"displayname" json:"displayName"`
+	Content     string  `xml:"content" json:"content"`
+	CreateTime  int64   `xml:"createtime" json:"createTime,omitempty"`
+}
+
+// WCPayInfo 红包、转账信息
+type WCPayInfo struct {
+	PaySubType        int    `xml:"paysubtype" json:"paySubType,omitempty"`
+	FeeDesc           string `xml:"feedesc" json:"feeDesc,omitempty"`
+	TransactionID     string `xml:"transcationid" json:"transactionId,omitempty"`
+	TransferID        string `xml:"transferid" json:"transferId,omitempty"`
+	InvalidTime       int64  `xml:"invalidtime" json:"invalidTime,omitempty"`
+	BeginTransferTime int64  `xml:"begintransfertime" json:"beginTransferTime,omitempty"`
+	PayMemo           string `xml:"pay_memo" json:"payMemo,omitempty"`
+	NativeURL         string `xml:"nativeurl" json:"nativeUrl,omitempty"`
+	IconURL           string `xml:"iconurl" json:"iconUrl,omitempty"`
+	ReceiverTitle     string `xml:"receivertitle" json:"receiverTitle,omitempty"`
+	SenderTitle       string `xml:"sendertitle" json:"senderTitle,omitempty"`
+	SceneText         string `xml:"scenetext" json:"sceneText,omitempty"`
+}
+
+// Link 链接分享
+type Link struct {
+	Title      string `json:"title"`
+	Des        string `json:"des,omitempty"`
+	URL        string `json:"url"`
+	ThumbURL   string `json:"thumbUrl,omitempty"`
+	SourceName string `json:"sourceName,omitempty"`
+	SourceUser string `json:"sourceUser,omitempty"`
+}
+
+// File 文件附件
+type File struct {
+	Name     string `json:"name"`
+	Ext      string `json:"ext"`
+	Size     int64  `json:"size"`
+	AttachID string `json:"attachId,omitempty"`
+	CdnURL   string `json:"cdnUrl,omitempty"`
+	AesKey   string `json:"aesKey,omitempty"`
+	MD5      string `json:"md5,omitempty"`
+}
+
+// MiniProgram 小程序
+type MiniProgram struct {
+	Title      string `json:"title"`
+	AppID      string `json:"appId"`
+	Username   string `json:"username"`
+	PagePath   string `json:"pagePath,omitempty"`
+	IconURL    string `json:"iconUrl,omitempty"`
+	SourceName string `json:"sourceName,omitempty"`
+}
+
+// Quote 引用回复
+type Quote struct {
+	Text        string  `json:"text"` // 回复内容
+	ReferType   MsgType `json:"referType"`
+	ReferMsgID  int64   `json:"referMsgId"`
+	FromUser    string  `json:"fromUser"` // 被引用消息的发送者
+	DisplayName string  `json:"displayName"`
+	Content     string  `json:"content"` // 被引用消息内容
+	CreateTime  int64   `json:"createTime,omitempty"`
+}
+
+// ChatHistory 聊天记录合集
+type ChatHistory struct {
+	Title string            `json:"title"`
+	Desc  string            `json:"desc"`
+	Items []ChatHistoryItem `json:"items"`
+}
+
+// ChatHistoryItem 聊天记录中的一条消息
+type ChatHistoryItem struct {
+	DataType   int    `xml:"datatype,attr" json:"dataType"`
+	SourceName string `xml:"sourcename" json:"sourceName"`
+	SourceTime string `xml:"sourcetime" json:"sourceTime"`
+	Desc       string `xml:"datadesc" json:"desc"`
+}
+
+// RedPacket 红包
+type RedPacket struct {
+	Wishing     string `json:"wishing"` // 祝福语
+	SenderTitle string `json:"senderTitle,omitempty"`
+	SceneText   string `json:"sceneText,omitempty"`
+	NativeURL   string `json:"nativeUrl,omitempty"`
+	IconURL     string `json:"iconUrl,omitempty"`
+}
+
+// TransferStatus 转账状态 paysubtype
+type TransferStatus int
+
+const (
+	TransferStatusSent     TransferStatus = 1 // 发起转账
+	TransferStatusReceived TransferStatus = 3 // 已收款
+	TransferStatusRefunded TransferStatus = 4 // 已退还
+)
+
+// Transfer 转账
+type Transfer struct {
+	Status        TransferStatus `json:"status"`
+	FeeDesc       string         `json:"feeDesc"` // 金额描述，如 ￥0.01
+	Memo          string         `json:"memo,omitempty"`
+	TransactionID string         `json:"transactionId"`
+	TransferID    string         `json:"transferId"`
+	BeginTime     int64          `json:"beginTime,omitempty"`
+	InvalidTime   int64          `json:"invalidTime,omitempty"`
+}
+
+type appMsgEnvelope struct {
+	XMLName      xml.Name `xml:"msg"`
+	AppMsg       *AppMsg  `xml:"appmsg"`
+	FromUsername string   `xml:"fromusername"`
+}
+
+// ParseAppMsg 解析 <msg><appmsg>...</appmsg></msg> XML
+func ParseAppMsg(data string) (*AppMsg, error) {
+	var env appMsgEnvelope
+	if err := xml.Unmarshal([]byte(strings.TrimSpace(data)), &env); err != nil {
+		return nil, fmt.Errorf("%w: appmsg: %w", ErrPayloadDecode, err)
+	}
+	if env.AppMsg == nil {
+		return nil, fmt.Errorf("%w: missing <appmsg>", ErrPayloadDecode)
+	}
+	return env.AppMsg, nil
+}
+
+// AppMsg 解析应用消息（type 49）
+func (m *Message) AppMsg() (*AppMsg, error) {
+	if err := m.expectType(MsgTypeApp); err != nil {
+		return nil, err
+	}
+	return ParseAppMsg(m.xmlPayload())
+}
+
+func (a *AppMsg) expectType(types ...AppMsgType) error {
+	for _, t := range types {
+		if a.Type == t {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: got appmsg type %d, want %d", ErrMsgTypeMismatch, a.Type, types[0])
+}
+
+// AsLink 链接分享
+func (a *AppMsg) AsLink() (*Link, error) {
+	if err := a.expectType(AppMsgTypeLink); err != nil {
+		return nil, err
+	}
+	return &Link{
+		Title:      a.Title,
+		Des:        a.Des,
+		URL:        a.URL,
+		ThumbURL:   a.ThumbURL,
+		SourceName: a.SourceDisplayName,
+		SourceUser: a.SourceUsername,
+	}, nil
+}
+
+// AsFile 文件附件
+func (a *AppMsg) AsFile() (*File, error) {
+	if err := a.expectType(AppMsgTypeFile, AppMsgTypeFileUploading); err != nil {
+		return nil, err
+	}
+	f := &File{Name: a.Title, MD5: a.MD5}
+	if a.AppAttach != nil {
+		f.Ext = a.AppAttach.FileExt
+		f.Size = a.AppAttach.TotalLen
+		f.AttachID = a.AppAttach.AttachID
+		f.CdnURL = a.AppAttach.CdnAttachURL
+		f.AesKey = a.AppAttach.AesKey
+	}
+	return f, nil
+}
+
+// AsMiniProgram 小程序
+func (a *AppMsg) AsMiniProgram() (*MiniProgram, error) {
+	if err := a.expectType(AppMsgTypeMiniProgram, AppMsgTypeMiniProgramPage); err != nil {
+		return nil, err
+	}
+	mp := &MiniProgram{Title: a.Title, SourceName: a.SourceDisplayName}
+	if a.WeAppInfo != nil {
+		mp.AppID = a.WeAppInfo.AppID
+		mp.Username = a.WeAppInfo.Username
+		mp.PagePath = a.WeAppInfo.PagePath
+		mp.IconURL = a.WeAppInfo.WeAppIconURL
+	}
+	return mp, nil
+}
+
+// AsQuote 引用回复
+func (a *AppMsg) AsQuote() (*Quote, error) {
+	if err := a.expectType(AppMsgTypeQuote); err != nil {
+		return nil, err
+	}
+	if a.ReferMsg == nil {
+		return nil, fmt.Errorf("%w: missing <refermsg>", ErrPayloadDecode)
+	}
+	id, _ := strconv.ParseInt(a.ReferMsg.SvrID, 10, 64)
+	fromUser := a.ReferMsg.FromUser
+	if a.ReferMsg.ChatUser != "" { // 引用群消息时 <fromusr> 为群 id，<chatusr> 才是被引用消息的发送者
+		fromUser = a.ReferMsg.ChatUser
+	}
+	return &Quote{
+		Text:        a.Title,
+		ReferType:   a.ReferMsg.Type,
+		ReferMsgID:  id,
+		FromUser:    fromUser,
+		DisplayName: a.ReferMsg.DisplayName,
+		Content:     a.ReferMsg.Content,
+		CreateTime:  a.ReferMsg.CreateTime,
+	}, nil
+}
+
+// AsChatHistory 聊天记录合集
+func (a *AppMsg) AsChatHistory() (*ChatHistory, error) {
+	if err := a.expectType(AppMsgTypeChatHistory); err != nil {
+		return nil, err
+	}
+	var record struct {
+		Title    string            `xml:"title"`
+		Desc     string            `xml:"desc"`
+		DataList []ChatHistoryItem `xml:"datalist>dataitem"`
+	}
+	if strings.TrimSpace(a.RecordItem) != "" {
+		if err := xml.Unmarshal([]byte(strings.TrimSpace(a.RecordItem)), &record); err != nil {
+			return nil, fmt.Errorf("%w: recorditem: %w", ErrPayloadDecode, err)
+		}
+	}
+	history := &ChatHistory{Title: a.Title, Desc: a.Des, Items: record.DataList}
+	if record.Title != "" {
+		history.Title = record.Title
+	}
+	if record.Desc != "" {
+		history.Desc = record.Desc
+	}
+	return history, nil
+}
+
+// AsRedPacket 红包
+func (a *AppMsg) AsRedPacket() (*RedPacket, error) {
+	if err := a.expectType(AppMsgTypeRedPacket); err != nil {
+		return nil, err
+	}
+	rp := &RedPacket{Wishing: a.Des}
+	if a.WCPayInfo != nil {
+		if a.WCPayInfo.ReceiverTitle != "" {
+			rp.Wishing = a.WCPayInfo.ReceiverTitle
+		}
+		rp.SenderTitle = a.WCPayInfo.SenderTitle
+		rp.SceneText = a.WCPayInfo.SceneText
+		rp.NativeURL = a.WCPayInfo.NativeURL
+		rp.IconURL = a.WCPayInfo.IconURL
+	}
+	return rp, nil
+}
+
+// AsTransfer 转账
+func (a *AppMsg) AsTransfer() (*Transfer, error) {
+	if err := a.expectType(AppMsgTypeTransfer); err != nil {
+		return nil, err
+	}
+	if a.WCPayInfo == nil {
+		return nil, fmt.Errorf("%w: missing <wcpayinfo>", ErrPayloadDecode)
+	}
+	return &Transfer{
+		Status:        TransferStatus(a.WCPayInfo.PaySubType),
+		FeeDesc:       a.WCPayInfo.FeeDesc,
+		Memo:          a.WCPayInfo.PayMemo,
+		TransactionID: a.WCPayInfo.TransactionID,
+		TransferID:    a.WCPayInfo.TransferID,
+		BeginTime:     a.WCPayInfo.BeginTransferTime,
+		InvalidTime:   a.WCPayInfo.InvalidTime,
+	}, nil
+}
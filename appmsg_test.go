@@ -0,0 +1,88 @@
+package wxhelper_sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+// 从 testdata/appmsg/*.xml 读取抓取的样例，与 *.golden.json 对比解析结果
+func TestParseAppMsg_Golden(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "appmsg", "*.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no appmsg samples found")
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".xml")
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			msg := &Message{Type: MsgTypeApp, FromUser: "34757816141@chatroom", Content: "wxid_sender01:\n" + string(data)}
+			appMsg, err := msg.AppMsg()
+			if err != nil {
+				t.Fatal(err)
+			}
+			detail, err := appMsgDetail(appMsg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.MarshalIndent(map[string]any{"appMsg": appMsg, "detail": detail}, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := strings.TrimSuffix(file, ".xml") + ".golden.json"
+			if *updateGolden {
+				if err := os.WriteFile(golden, append(got, '\n'), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("read golden file failed (run with -update to create): %v", err)
+			}
+			assert.JSONEq(t, string(want), string(got))
+		})
+	}
+}
+
+func appMsgDetail(a *AppMsg) (any, error) {
+	switch a.Type {
+	case AppMsgTypeLink:
+		return a.AsLink()
+	case AppMsgTypeFile:
+		return a.AsFile()
+	case AppMsgTypeMiniProgram, AppMsgTypeMiniProgramPage:
+		return a.AsMiniProgram()
+	case AppMsgTypeQuote:
+		return a.AsQuote()
+	case AppMsgTypeChatHistory:
+		return a.AsChatHistory()
+	case AppMsgTypeRedPacket:
+		return a.AsRedPacket()
+	case AppMsgTypeTransfer:
+		return a.AsTransfer()
+	}
+	return nil, nil
+}
+
+func TestAppMsg_TypeMismatch(t *testing.T) {
+	appMsg, err := ParseAppMsg(`<msg><appmsg><title>t</title><type>5</type></appmsg></msg>`)
+	assert.Nil(t, err)
+	_, err = appMsg.AsTransfer()
+	assert.True(t, errors.Is(err, ErrMsgTypeMismatch))
+
+	_, err = (&Message{Type: MsgTypeText, Content: "hi"}).AppMsg()
+	assert.True(t, errors.Is(err, ErrMsgTypeMismatch))
+}
@@ -0,0 +1,288 @@
+// Package wxhelper_sdk
+// @Author Clover
+// @Data 2025/1/21 下午3:10:00
+// @Desc 群聊信息：成员列表解析与缓存
+package wxhelper_sdk
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"wxhelper-sdk/inner/models"
+)
+
+const (
+	DefaultChatRoomTTL = 10 * time.Minute
+	memberSeparator    = "^G" // getMemberFromChatRoom 中成员、昵称的分隔符
+)
+
+var ErrNotChatRoom = errors.New("not a chatroom id")
+
+// reMembership 成员变动的系统消息文本，用于无法解析为群事件时判断是否使缓存失效
+var reMembership = regexp.MustCompile(`(?:加入|移出|退出)了?群聊`)
+
+// Member 群成员
+type Member struct {
+	Wxid        string `json:"wxid"`
+	DisplayName string `json:"displayName"` // 群昵称，未设置时为微信昵称
+	IsAdmin     bool   `json:"isAdmin"`     // 群主或管理员
+}
+
+// ChatRoomData 群详情中的 XML，解析失败时 ChatRoom.Data 为空
+//
+//	<ChatRoomData>
+//	  <Member UserName="wxid_xxx"><DisplayName>群昵称</DisplayName></Member>
+//	  <RoomAdmins>wxid_a;wxid_b</RoomAdmins>
+//	</ChatRoomData>
+type ChatRoomData struct {
+	Members []struct {
+		UserName    string `xml:"UserName,attr"`
+		DisplayName string `xml:"DisplayName"`
+	} `xml:"Member"`
+	RoomAdmins string `xml:"RoomAdmins"` // 管理员 wxid，分号分隔
+}
+
+// ParseChatRoomData 解析群详情 XML
+func ParseChatRoomData(data string) (*ChatRoomData, error) {
+	data = strings.TrimSpace(data)
+	var room ChatRoomData
+	if data == "" {
+		return &room, nil
+	}
+	if err := xml.Unmarshal([]byte(data), &room); err != nil {
+		return nil, fmt.Errorf("%w: chatroom: %w", ErrPayloadDecode, err)
+	}
+	return &room, nil
+}
+
+// Admins 管理员 wxid 列表
+func (d *ChatRoomData) Admins() []string {
+	var wxids []string
+	for _, wxid := range strings.Split(d.RoomAdmins, ";") {
+		if wxid = strings.TrimSpace(wxid); wxid != "" {
+			wxids = append(wxids, wxid)
+		}
+	}
+	return wxids
+}
+
+// ChatRoom 群聊
+type ChatRoom struct {
+	ID        string        `json:"chatRoomId"`
+	Name      string        `json:"name"`  // 群名，来自联系人目录或改群名事件
+	Owner     string        `json:"owner"` // 群主 wxid
+	OwnerName string        `json:"ownerName"`
+	Notice    string        `json:"notice"` // 群公告
+	Members   []Member      `json:"members"`
+	Data      *ChatRoomData `json:"-"`
+	UpdatedAt time.Time     `json:"updatedAt"`
+}
+
+// newChatRoom 由成员列表与群详情组装群信息，detail 可为空
+func newChatRoom(group *models.GroupMember, detail *models.ChatRoomInfo) *ChatRoom {
+	room := &ChatRoom{
+		ID:        group.ChatRoomID,
+		Owner:     group.Admin,
+		OwnerName: group.AdminNickname,
+		UpdatedAt: time.Now(),
+	}
+	if detail != nil {
+		room.Notice = detail.Notice
+		if room.Owner == "" {
+			room.Owner = detail.Admin
+		}
+		if data, err := ParseChatRoomData(detail.XML); err == nil {
+			room.Data = data
+		}
+	}
+	admins := map[string]bool{room.Owner: true}
+	names := make(map[string]string)
+	if room.Data != nil {
+		for _, wxid := range room.Data.Admins() {
+			admins[wxid] = true
+		}
+		for _, m := range room.Data.Members {
+			if m.DisplayName != "" {
+				names[m.UserName] = m.DisplayName
+			}
+		}
+	}
+	wxids := splitMembers(group.Members)
+	nicknames := strings.Split(group.MemberNickname, memberSeparator)
+	if len(nicknames) == len(wxids)+1 && nicknames[0] == "" { // 昵称以分隔符开头
+		nicknames = nicknames[1:]
+	}
+	for i, wxid := range wxids {
+		name := names[wxid]
+		if name == "" && i < len(nicknames) {
+			name = nicknames[i]
+		}
+		room.Members = append(room.Members, Member{Wxid: wxid, DisplayName: name, IsAdmin: admins[wxid]})
+	}
+	return room
+}
+
+func splitMembers(s string) []string {
+	var wxids []string
+	for _, wxid := range strings.Split(s, memberSeparator) {
+		if wxid = strings.TrimSpace(wxid); wxid != "" {
+			wxids = append(wxids, wxid)
+		}
+	}
+	return wxids
+}
+
+// clone 返回副本，缓存中的群信息不会被调用方修改
+func (r *ChatRoom) clone() *ChatRoom {
+	room := *r
+	room.Members = slices.Clone(r.Members)
+	if r.Data != nil {
+		data := *r.Data
+		data.Members = slices.Clone(r.Data.Members)
+		room.Data = &data
+	}
+	return &room
+}
+
+// Member 按 wxid 查找群成员
+func (r *ChatRoom) Member(wxid string) (Member, bool) {
+	i := slices.IndexFunc(r.Members, func(m Member) bool { return m.Wxid == wxid })
+	if i < 0 {
+		return Member{}, false
+	}
+	return r.Members[i], true
+}
+
+// HasMember 是否为群成员
+func (r *ChatRoom) HasMember(wxid string) bool {
+	_, ok := r.Member(wxid)
+	return ok
+}
+
+// IsAdmin 是否为群主或管理员
+func (r *ChatRoom) IsAdmin(wxid string) bool {
+	m, ok := r.Member(wxid)
+	return ok && m.IsAdmin
+}
+
+// Admins 群主及管理员
+func (r *ChatRoom) Admins() []Member {
+	var admins []Member
+	for _, m := range r.Members {
+		if m.IsAdmin {
+			admins = append(admins, m)
+		}
+	}
+	return admins
+}
+
+// Wxids 全部成员 wxid
+func (r *ChatRoom) Wxids() []string {
+	wxids := make([]string, len(r.Members))
+	for i, m := range r.Members {
+		wxids[i] = m.Wxid
+	}
+	return wxids
+}
+
+// chatRoomCache 群信息缓存，超过 ttl 或收到成员变动的系统消息后重新获取
+type chatRoomCache struct {
+	mu    sync.Mutex
+	ttl   time.Duration // 0 表示仅在失效时重新获取
+	rooms map[string]*ChatRoom
+}
+
+func newChatRoomCache(ttl time.Duration) *chatRoomCache {
+	return &chatRoomCache{ttl: ttl, rooms: make(map[string]*ChatRoom)}
+}
+
+func (c *chatRoomCache) get(id string) (*ChatRoom, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	room, ok := c.rooms[id]
+	if !ok || (c.ttl > 0 && time.Since(room.UpdatedAt) > c.ttl) {
+		return nil, false
+	}
+	return room.clone(), true
+}
+
+func (c *chatRoomCache) put(room *ChatRoom) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rooms[room.ID] = room.clone()
+}
+
+func (c *chatRoomCache) invalidate(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.rooms, id)
+}
+
+func (c *chatRoomCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.rooms)
+}
+
+// observe 群内成员变动（入群、退群、踢人等）的系统消息使缓存失效，拍一拍、红包等其他系统消息不影响缓存
+func (c *chatRoomCache) observe(m *Message) {
+	id := m.ChatRoomID()
+	if id == "" {
+		return
+	}
+	switch m.Type {
+	case MsgTypeSys:
+		if reMembership.MatchString(m.Text()) {
+			c.invalidate(id)
+		}
+	case MsgTypeRecalled:
+		if strings.Contains(m.Content, "chatroommember") {
+			c.invalidate(id)
+		}
+	}
+}
+
+// ChatRoom 获取群信息（成员、群主、公告），优先读取缓存，返回的群信息为副本
+func (c *Client) ChatRoom(ctx context.Context, chatRoomID string) (*ChatRoom, error) {
+	if room, ok := c.chatRooms.get(chatRoomID); ok {
+		return room, nil
+	}
+	return c.RefreshChatRoom(ctx, chatRoomID)
+}
+
+// RefreshChatRoom 从 wxhelper 重新获取群信息并更新缓存，返回的群信息为副本
+func (c *Client) RefreshChatRoom(ctx context.Context, chatRoomID string) (*ChatRoom, error) {
+	if !isChatRoomID(chatRoomID) {
+		return nil, fmt.Errorf("%w: %q", ErrNotChatRoom, chatRoomID)
+	}
+	group, err := c.wxClient.GetMemberFromChatRoom(ctx, chatRoomID)
+	if err != nil {
+		return nil, fmt.Errorf("get chatroom member err: %w", err)
+	}
+	if group.ChatRoomID == "" {
+		group.ChatRoomID = chatRoomID
+	}
+	// 群详情仅补充公告与管理员，获取失败时不影响成员列表
+	detail, err := c.wxClient.GetChatRoomDetail(ctx, chatRoomID)
+	if err != nil {
+		c.log.WarnWithErr(err, "get chatroom detail error", map[string]interface{}{"chatRoomId": chatRoomID})
+		detail = nil
+	}
+	room := newChatRoom(group, detail)
+	if contact, ok := c.contacts.Get(chatRoomID); ok {
+		room.Name = contact.Nickname
+	}
+	c.chatRooms.put(room)
+	return room, nil
+}
+
+// InvalidateChatRoom 使群信息缓存失效，下次 ChatRoom 时重新获取
+func (c *Client) InvalidateChatRoom(chatRoomID string) {
+	c.chatRooms.invalidate(chatRoomID)
+}
@@ -0,0 +1,121 @@
+package wxhelper_sdk
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+const (
+	chatRoomMemberJSON = `{"code":1,"msg":"success","data":{"chatRoomId":"123@chatroom","admin":"wxid_owner","adminNickname":"群主",
+"members":"wxid_owner^Gwxid_a^Gwxid_b","memberNickname":"^G群主^GAlice^GBob"}}`
+	chatRoomDetailJSON = `{"code":1,"msg":"success","data":{"chatRoomId":"123@chatroom","admin":"wxid_owner","notice":"群公告",
+"xml":"<ChatRoomData><Member UserName=\"wxid_b\"><DisplayName>小B</DisplayName></Member><RoomAdmins>wxid_a</RoomAdmins></ChatRoomData>"}}`
+)
+
+// chatRoomServer 返回群成员与群详情，并记录获取群成员的次数
+type chatRoomServer struct {
+	detail   string
+	requests atomic.Int32
+}
+
+func (f *chatRoomServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/api/getMemberFromChatRoom":
+		f.requests.Add(1)
+		_, _ = w.Write([]byte(chatRoomMemberJSON))
+	case "/api/getChatRoomDetailInfo":
+		_, _ = w.Write([]byte(f.detail))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestClient_ChatRoom(t *testing.T) {
+	fake := &chatRoomServer{detail: chatRoomDetailJSON}
+	client := newTestClient(t, fake, WithRetryPolicy(RetryPolicy{}))
+	ctx := context.Background()
+
+	room, err := client.ChatRoom(ctx, "123@chatroom")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "wxid_owner", room.Owner)
+	assert.Equal(t, "群主", room.OwnerName)
+	assert.Equal(t, "群公告", room.Notice)
+	assert.Equal(t, []Member{
+		{Wxid: "wxid_owner", DisplayName: "群主", IsAdmin: true},
+		{Wxid: "wxid_a", DisplayName: "Alice", IsAdmin: true},
+		{Wxid: "wxid_b", DisplayName: "小B"}, // 群昵称优先
+	}, room.Members)
+	assert.True(t, room.IsAdmin("wxid_a"))
+	assert.False(t, room.IsAdmin("wxid_b"))
+	assert.False(t, room.HasMember("wxid_c"))
+	assert.Len(t, room.Admins(), 2)
+	assert.Equal(t, []string{"wxid_owner", "wxid_a", "wxid_b"}, room.Wxids())
+
+	// 命中缓存
+	_, err = client.ChatRoom(ctx, "123@chatroom")
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), fake.requests.Load())
+
+	// 返回副本，修改不影响缓存
+	room.Members[0].DisplayName = "changed"
+	room.Members = room.Members[:1]
+	room.Data.Members[0].DisplayName = "changed"
+	cached, _ := client.ChatRoom(ctx, "123@chatroom")
+	assert.Equal(t, "群主", cached.Members[0].DisplayName)
+	assert.Len(t, cached.Members, 3)
+	assert.Equal(t, "小B", cached.Data.Members[0].DisplayName)
+	assert.Equal(t, int32(1), fake.requests.Load())
+
+	// 群内其他消息及拍一拍、红包等系统消息不影响缓存，成员变动的系统消息使缓存失效
+	client.chatRooms.observe(&Message{FromUser: "123@chatroom", Type: MsgTypeText, Content: "hi"})
+	client.chatRooms.observe(&Message{FromUser: "123@chatroom", Type: MsgTypeSys, Content: `"Alice" 拍了拍 "Bob"`})
+	client.chatRooms.observe(&Message{FromUser: "123@chatroom", Type: MsgTypeSys, Content: "Alice领取了你的红包"})
+	_, _ = client.ChatRoom(ctx, "123@chatroom")
+	assert.Equal(t, int32(1), fake.requests.Load())
+	client.chatRooms.observe(&Message{FromUser: "123@chatroom", Type: MsgTypeSys, Content: `"Alice"邀请"Carol"加入了群聊`})
+	_, _ = client.ChatRoom(ctx, "123@chatroom")
+	assert.Equal(t, int32(2), fake.requests.Load())
+
+	client.InvalidateChatRoom("123@chatroom")
+	_, _ = client.ChatRoom(ctx, "123@chatroom")
+	assert.Equal(t, int32(3), fake.requests.Load())
+
+	_, err = client.ChatRoom(ctx, "wxid_a")
+	assert.True(t, errors.Is(err, ErrNotChatRoom))
+}
+
+func TestClient_ChatRoomDetailFailed(t *testing.T) {
+	client := newTestClient(t, &chatRoomServer{detail: `{"code":0,"msg":"fail","data":{}}`}, WithRetryPolicy(RetryPolicy{}))
+	room, err := client.ChatRoom(context.Background(), "123@chatroom")
+	if assert.Nil(t, err) {
+		assert.Nil(t, room.Data)
+		assert.Equal(t, "", room.Notice)
+		assert.Equal(t, []string{"wxid_owner"}, wxidsOf(room.Admins()))
+		assert.Equal(t, "Bob", room.Members[2].DisplayName)
+	}
+}
+
+func wxidsOf(members []Member) []string {
+	wxids := make([]string, len(members))
+	for i, m := range members {
+		wxids[i] = m.Wxid
+	}
+	return wxids
+}
+
+func TestParseChatRoomData(t *testing.T) {
+	data, err := ParseChatRoomData(" ")
+	assert.Nil(t, err)
+	assert.Empty(t, data.Admins())
+	data, err = ParseChatRoomData(`<ChatRoomData><RoomAdmins>wxid_a; wxid_b;</RoomAdmins></ChatRoomData>`)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"wxid_a", "wxid_b"}, data.Admins())
+	_, err = ParseChatRoomData("<ChatRoomData>")
+	assert.True(t, errors.Is(err, ErrPayloadDecode))
+}
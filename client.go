@@ -8,9 +8,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"github.com/eatmoreapple/env"
 	"github.com/rs/zerolog"
+	"sync"
+	"sync/atomic"
+	"time"
 	"wxhelper-sdk/inner"
+	"wxhelper-sdk/inner/manager"
 	"wxhelper-sdk/logging"
 )
 
@@ -18,28 +21,76 @@ const (
 	ENVTcpAddr          = "TCP_ADDR"
 	ENVWxApiBaseUrl     = "WX_API_BASE_URL"
 	ENVTcpHookURL       = "WX_HOOK_URL"
+	ENVHookMode         = "WX_HOOK_MODE"     // tcp(默认) 或 http
+	ENVHttpHookURL      = "WX_HTTP_HOOK_URL" // http 模式下 wxhelper 回调的地址
+	ENVHookTimeout      = "WX_HOOK_TIMEOUT"  // http 模式下 wxhelper 回调的超时时间（秒）
 	DefaultTcpAddr      = "19099"
 	DefaultWxApiBaseUrl = "http://127.0.0.1:19088"
 	DefaultTcpHookURL   = "127.0.0.1:19089"
+	HookModeTCP         = "tcp"
+	HookModeHTTP        = "http"
 )
 
 var (
-	ErrNotLogin = errors.New("not login")
+	ErrNotLogin     = errors.New("not login")
+	ErrClientClosed = errors.New("client closed")
 )
 
 type Client struct {
-	listener  *TCPMessageListener
+	listener  MessageListener
 	ctx       context.Context
 	stop      context.CancelFunc
 	msgBuffer *MessageBuffer
 	wxClient  *inner.WxClient
-	isLogin   bool
+	cache     manager.ICacheManager // 图片、文件缓存
+	retention time.Duration         // 缓存保留时间
+	log       *logging.FieldLogger
+	account   atomic.Pointer[Account] // 当前登录账号
+	contacts  *Contacts
+	chatRooms *chatRoomCache
+	recent    *messageStore // 最近收到的消息，用于查找被撤回的原消息
+	queue     *SendQueue    // WithSendQueue 配置的发送队列，为空时直接发送
+
+	state         atomic.Int32 // State
+	loginFailures atomic.Int32 // 连续无法请求 checkLogin 的次数
+	loginPolicy   LoginPolicy
+	hooks         loginHooks
+	audit         auditHooks
+	groupEvents   groupEventHooks
+	revokes       revokeHooks
+	serving       sync.WaitGroup // 正在运行的 Serve
+}
+
+// Self 当前登录的账号，未登录时返回 nil
+func (c *Client) Self() *Account {
+	return c.account.Load()
+}
+
+// refreshAccount 重新获取登录账号信息
+func (c *Client) refreshAccount(ctx context.Context) (*Account, error) {
+	info, err := c.wxClient.GetUserInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get user info err: %w", err)
+	}
+	account := newAccount(info)
+	c.account.Store(account)
+	c.log.Info("account info refreshed", map[string]interface{}{"account": account})
+	return account, nil
 }
 
 // GetMsg 获取消息对
 func (c *Client) GetMsg() (*Message, error) {
-	if !c.isLogin {
-		logging.Warn("客户端并未登录成功，请稍重试")
+	switch c.State() {
+	case StateClosed:
+		return nil, ErrClientClosed
+	case StateClosing: // 关闭中仍可取走剩余消息，但不再等待新消息
+		if msg, ok := c.msgBuffer.tryGet(); ok {
+			return msg, nil
+		}
+		return nil, ErrClientClosed
+	}
+	if c.State() != StateLoggedIn {
+		c.log.Warn("客户端并未登录成功，请稍重试")
 		return nil, ErrNotLogin
 	}
 	msgPair, err := c.msgBuffer.Get(c.ctx)
@@ -49,9 +100,55 @@ func (c *Client) GetMsg() (*Message, error) {
 	return msgPair, nil
 }
 
+// Serve 以推送的方式将消息分发给 handler（如 Dispatcher），阻塞直到 ctx 结束，可替代 GetMsg 轮询
+// 同时处理的消息数受 WithServeConcurrency 限制，达到上限时消息留在缓冲区中
+func (c *Client) Serve(ctx context.Context, handler MessageHandler, opts ...ServeOption) error {
+	if c.State() == StateClosed {
+		return ErrClientClosed
+	}
+	c.serving.Add(1)
+	defer c.serving.Done()
+	return serveBuffer(ctx, c.ctx, c.msgBuffer, handler, c.log, newServeOptions(opts))
+}
+
+// serveBuffer 持续从 buffer 取出消息并发交给 handler，ctx 或 stopCtx 结束时等待 handler 完成后返回
+func serveBuffer(ctx, stopCtx context.Context, buffer *MessageBuffer, handler MessageHandler, log *logging.FieldLogger, o serveOptions) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(stopCtx, cancel) // 客户端停止时同样退出
+	defer stop()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	sem := make(chan struct{}, o.concurrency)
+	for {
+		select { // 先占用名额再取消息，handler 满载时消息留在缓冲区
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		msg, err := buffer.Get(ctx)
+		if err != nil {
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := handler.HandleMessage(msg); err != nil {
+				log.ErrorWithErr(err, "serve message failed", map[string]interface{}{"msgId": msg.MsgId})
+			}
+		}()
+	}
+}
+
 func (c *Client) startListen() error {
 	var handler MessageHandlerFunc = func(message *Message) error {
-		message.handleFileTypeMsg() // 不同类型消息处理
+		message.client = c
+		message.account = c.Self()
+		message.handleFileTypeMsg(c.cache, c.log) // 不同类型消息处理
+		c.handleGroupEvent(message)               // 群事件回调与群信息缓存
+		c.handleRevoke(message)                   // 撤回事件回调与最近消息存储
 		err := c.msgBuffer.Put(c.ctx, message)
 		if err != nil {
 			return fmt.Errorf("MessageHandler err: %w", err)
@@ -59,27 +156,65 @@ func (c *Client) startListen() error {
 		return nil
 	}
 	err := c.listener.ListenAndServe(c.ctx, handler)
-	if err != nil {
+	if err != nil && !errors.Is(err, ErrListenerClosed) {
 		return fmt.Errorf("listener err: %w", err)
 	}
 	return nil
 }
 
-func NewClient(msgChanSize int) *Client {
-	addr := env.Name(ENVTcpAddr).StringOrElse(DefaultTcpAddr)                   // "19099"
-	WxApiBaseUrl := env.Name(ENVWxApiBaseUrl).StringOrElse(DefaultWxApiBaseUrl) // "http:// 127.0.0.1:19088"
-	tcpHookURL := env.Name(ENVTcpHookURL).StringOrElse(DefaultTcpHookURL)       //  "127.0.0.1:19089"
+// NewClient 创建客户端，未通过 Option 指定的配置回退到环境变量（TCP_ADDR、WX_API_BASE_URL、WX_HOOK_URL 等）与默认值
+func NewClient(opts ...Option) *Client {
+	o := optionsFromEnv()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	log := o.logger
+	if log == nil {
+		log = logging.Default()
+	}
+	wxClient := inner.NewWxClient(o.apiBaseURL, o.hookURL)
+	if o.httpClient != nil {
+		wxClient.SetHTTPClient(o.httpClient)
+	}
+	wxClient.SetTimeout(o.timeout)
+	wxClient.SetRetryPolicy(o.retry)
+	if o.observer != nil {
+		wxClient.SetObserver(o.observer)
+	}
+	listener := o.newListener(log) // tcp server、http server 或自定义实现
+	if setter, ok := listener.(loggerSetter); ok {
+		setter.setLogger(log)
+	}
 	ctx, cancel := context.WithCancel(context.Background())
-	return &Client{
-		listener:  NewTCPMessageListener(addr), // tcp server
-		ctx:       ctx,
-		stop:      cancel,
-		msgBuffer: NewMessageBuffer(msgChanSize), // 消息缓冲区 <缓冲大小>
-		wxClient:  inner.NewWxClient(WxApiBaseUrl, tcpHookURL),
+	c := &Client{
+		listener:    listener,
+		ctx:         ctx,
+		stop:        cancel,
+		msgBuffer:   newMessageBuffer(o.bufferSize, log), // 消息缓冲区 <缓冲大小>
+		wxClient:    wxClient,
+		cache:       manager.NewCacheManager(o.cacheDir),
+		retention:   o.retention,
+		log:         log,
+		contacts:    newContacts(wxClient, log, o.contactRefresh),
+		chatRooms:   newChatRoomCache(o.chatRoomTTL),
+		recent:      newMessageStore(o.revokeRetention, maxRecentMessages),
+		loginPolicy: o.loginPolicy,
 	}
+	if o.sendQueue != nil {
+		c.queue = NewSendQueue(c, *o.sendQueue)
+	}
+	return c
+}
+
+// NewClientWithListener 使用自定义的消息监听者创建客户端
+//
+// Deprecated: 使用 NewClient(WithBufferSize(msgChanSize), WithListener(listener))
+func NewClientWithListener(msgChanSize int, listener MessageListener) *Client {
+	return NewClient(WithBufferSize(msgChanSize), WithListener(listener))
 }
 
-// Run 运行tcp监听 以及 请求tcp监听信息
+// Run 运行tcp监听，并在后台持续检测登录状态（未登录时等待登录，掉线后自动重新 hook）
+// 若微信已登录，返回时已完成登录；监听失败（端口被占用、hook 配置有误等）时 panic
 func (c *Client) Run(debug bool) {
 	if debug {
 		zerolog.SetGlobalLevel(zerolog.DebugLevel)
@@ -93,15 +228,34 @@ func (c *Client) Run(debug bool) {
 			panic(err)
 		}
 	}()
-	err := c.wxClient.HookSyncMsg(c.ctx)
-	if err != nil {
-		logging.Error(err.Error())
+	c.state.CompareAndSwap(int32(StateInit), int32(StateWaitingLogin))
+	if !c.checkLogin(c.ctx) {
+		c.log.Warn("wechat is not logged in yet, waiting for login")
+	}
+	go c.watchLogin()
+	go c.contacts.run(c.ctx)
+	if c.retention > 0 {
+		go c.purgeCache()
 	}
+}
 
-	c.isLogin, err = c.wxClient.CheckLogin(c.ctx)
-	if err != nil {
-		logging.ErrorWithErr(err, "checkLogin error")
-		c.stop()
-		return
+// purgeCache 定期清理超过保留时间的缓存文件
+func (c *Client) purgeCache() {
+	interval := min(max(c.retention/2, time.Minute), time.Hour)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		removed, err := c.cache.Purge(time.Now().Add(-c.retention))
+		if err != nil {
+			c.log.WarnWithErr(err, "purge cache error")
+		}
+		if removed > 0 {
+			c.log.Debug("cache purged", map[string]interface{}{"removed": removed})
+		}
 	}
 }
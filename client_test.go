@@ -5,11 +5,29 @@
 package wxhelper_sdk
 
 import (
+	"context"
 	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
+// newTestClient 创建已登录的客户端，wxhelper 接口由 handler 模拟，opts 在默认测试配置之后应用
+func newTestClient(t *testing.T, handler http.Handler, opts ...Option) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	all := append([]Option{WithAPIBaseURL(server.URL), WithCacheDir(t.TempDir())}, opts...)
+	client := NewClient(all...)
+	t.Cleanup(client.stop)
+	client.state.Store(int32(StateLoggedIn))
+	return client
+}
+
 func TestClient_GetMsg(t *testing.T) {
 	os.Setenv(ENVTcpAddr, "19089")
 	os.Setenv(ENVWxApiBaseUrl, "http://127.0.0.1:19088")
@@ -19,7 +37,7 @@ func TestClient_GetMsg(t *testing.T) {
 		os.Unsetenv(ENVWxApiBaseUrl)
 		os.Unsetenv(ENVTcpHookURL)
 	}()
-	client := NewClient(100)
+	client := NewClient(WithBufferSize(100))
 	client.Run(true)
 
 	var n = 10 // 接收消息的个数，会阻塞
@@ -32,3 +50,35 @@ func TestClient_GetMsg(t *testing.T) {
 	}
 
 }
+
+func TestClient_ServeConcurrency(t *testing.T) {
+	client := NewClient(WithCacheDir(t.TempDir()), WithBufferSize(10))
+	defer client.stop()
+	for i := 1; i <= 5; i++ {
+		assert.Nil(t, client.msgBuffer.Put(context.Background(), &Message{MsgId: int64(i)}))
+	}
+
+	var active, handled atomic.Int32
+	gate := make(chan struct{})
+	handler := MessageHandlerFunc(func(message *Message) error {
+		active.Add(1)
+		defer active.Add(-1)
+		<-gate
+		handled.Add(1)
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- client.Serve(ctx, handler, WithServeConcurrency(2)) }()
+
+	// 达到上限后不再取出消息
+	assert.Eventually(t, func() bool { return active.Load() == 2 }, time.Second, 5*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int32(2), active.Load())
+	assert.Equal(t, 3, client.msgBuffer.Len())
+
+	close(gate)
+	assert.Eventually(t, func() bool { return handled.Load() == 5 }, time.Second, 5*time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-served, context.Canceled)
+}
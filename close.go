@@ -0,0 +1,118 @@
+// Package wxhelper_sdk
+// @Author Clover
+// @Data 2025/1/14 下午4:10:00
+// @Desc 客户端优雅关闭
+package wxhelper_sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// drainIdleTimeout 关闭时等待消费者取走缓冲消息，超过该时长无进展则不再等待
+const drainIdleTimeout = time.Second
+
+// Close 关闭客户端：取消 wxhelper 的消息 hook、立即关闭监听、等待正在处理的消息，
+// 并等待缓冲区中的消息被 GetMsg/Serve 取走（此期间状态为 StateClosing），ctx 结束前未取走的消息会持久化到缓存目录下的 jsonl 文件
+func (c *Client) Close(ctx context.Context) error {
+	if !c.transition(StateClosing) {
+		return nil
+	}
+	var errs []error
+
+	// 1. 取消 hook，wxhelper 不再推送消息
+	if _, ok := c.listener.(HookRegistrar); ok {
+		if err := c.wxClient.UnhookSyncMsg(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("unhook sync msg: %w", err))
+		}
+	}
+
+	// 2. 关闭监听并等待正在处理的连接
+	if err := c.listener.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("shutdown listener: %w", err))
+	}
+
+	// 3. 等待消费者取走缓冲区中的消息，并等待发送队列中的回复发出
+	waitDrain(ctx, c.msgBuffer)
+	if c.queue != nil {
+		if err := c.queue.Close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("close send queue: %w", err))
+		}
+	}
+	c.setState(StateClosed)
+
+	// 4. 停止登录检测与 Serve，并等待 Serve 中的 handler 完成
+	c.stop()
+	if err := waitGroupWithContext(ctx, &c.serving); err != nil {
+		errs = append(errs, fmt.Errorf("wait serving handlers: %w", err))
+	}
+
+	// 5. 持久化剩余消息
+	if remaining := c.msgBuffer.drain(); len(remaining) > 0 {
+		path, err := persistMessages(c.cache.Dir(), remaining)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("persist %d pending messages: %w", len(remaining), err))
+		} else {
+			c.log.Warn("pending messages persisted", map[string]interface{}{"count": len(remaining), "path": path})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// waitDrain 等待缓冲区被取空，ctx 结束或长时间无进展时返回
+func waitDrain(ctx context.Context, buffer *MessageBuffer) {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	last, idleSince := buffer.Len(), time.Now()
+	for n := last; n > 0; n = buffer.Len() {
+		if n < last {
+			last, idleSince = n, time.Now()
+		} else if time.Since(idleSince) > drainIdleTimeout {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// persistMessages 将消息以 jsonl 格式写入缓存目录
+func persistMessages(dir string, msgs []*Message) (string, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("pending_messages_%d.jsonl", time.Now().UnixNano()))
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = file.Close() }()
+	encoder := json.NewEncoder(file)
+	for _, msg := range msgs {
+		if err = encoder.Encode(msg); err != nil {
+			return "", err
+		}
+	}
+	return path, nil
+}
+
+func waitGroupWithContext(ctx context.Context, wg interface{ Wait() }) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
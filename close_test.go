@@ -0,0 +1,125 @@
+package wxhelper_sdk
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// listenAddr 等待监听启动并返回实际地址
+func listenAddr(t *testing.T, tl *TCPMessageListener) string {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		tl.mu.Lock()
+		ln := tl.listener
+		tl.mu.Unlock()
+		if ln != nil {
+			return ln.Addr().String()
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("listener not started")
+	return ""
+}
+
+func sendTCPMessage(t *testing.T, addr string, msg *Message) {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+	if err = json.NewEncoder(conn).Encode(msg); err != nil {
+		t.Fatal(err)
+	}
+	ack := make([]byte, 16)
+	_, _ = conn.Read(ack)
+}
+
+func TestClient_Close(t *testing.T) {
+	fake := &fakeWxHelper{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	tempDir := t.TempDir()
+
+	client := NewClient(WithAPIBaseURL(server.URL), WithListenAddr("0"), WithCacheDir(tempDir), WithBufferSize(10))
+	client.state.Store(int32(StateLoggedIn))
+	listenErr := make(chan error, 1)
+	go func() { listenErr <- client.startListen() }()
+	addr := listenAddr(t, client.listener.(*TCPMessageListener))
+
+	sendTCPMessage(t, addr, &Message{MsgId: 1, Type: MsgTypeText, FromUser: "wxid_a", Content: "first"})
+	msg, err := client.GetMsg()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), msg.MsgId)
+
+	// 未被消费的消息在关闭时持久化
+	sendTCPMessage(t, addr, &Message{MsgId: 2, Type: MsgTypeText, FromUser: "wxid_a", Content: "second"})
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	assert.Nil(t, client.Close(ctx))
+	assert.Equal(t, StateClosed, client.State())
+
+	select {
+	case err = <-listenErr:
+		assert.Nil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("listener did not stop")
+	}
+	files, _ := filepath.Glob(filepath.Join(tempDir, "pending_messages_*.jsonl"))
+	if assert.Len(t, files, 1) {
+		data, _ := os.ReadFile(files[0])
+		assert.Contains(t, string(data), `"content":"second"`)
+	}
+
+	// 重复关闭
+	assert.Nil(t, client.Close(ctx))
+	_, err = client.GetMsg()
+	assert.Equal(t, ErrClientClosed, err)
+}
+
+func TestClient_CloseDrainByGetMsg(t *testing.T) {
+	fake := &fakeWxHelper{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	tempDir := t.TempDir()
+
+	client := NewClient(WithAPIBaseURL(server.URL), WithListenAddr("0"), WithCacheDir(tempDir), WithBufferSize(10))
+	client.state.Store(int32(StateLoggedIn))
+	go func() { _ = client.startListen() }()
+	listenAddr(t, client.listener.(*TCPMessageListener))
+	for i := 1; i <= 3; i++ {
+		assert.Nil(t, client.msgBuffer.Put(context.Background(), &Message{MsgId: int64(i), Type: MsgTypeText, FromUser: "wxid_a"}))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	closed := make(chan error, 1)
+	go func() { closed <- client.Close(ctx) }()
+	for client.State() != StateClosing {
+		time.Sleep(time.Millisecond)
+	}
+
+	// 关闭中 GetMsg 仍可取走剩余消息，取空后返回 ErrClientClosed
+	var ids []int64
+	for {
+		msg, err := client.GetMsg()
+		if err != nil {
+			assert.Equal(t, ErrClientClosed, err)
+			break
+		}
+		ids = append(ids, msg.MsgId)
+	}
+	assert.Equal(t, []int64{1, 2, 3}, ids)
+	assert.Nil(t, <-closed)
+	assert.Equal(t, StateClosed, client.State())
+	files, _ := filepath.Glob(filepath.Join(tempDir, "pending_messages_*.jsonl"))
+	assert.Empty(t, files)
+}
@@ -0,0 +1,312 @@
+// Package config
+// @Author Clover
+// @Data 2025/1/17 上午10:30:00
+// @Desc 从配置文件（YAML/TOML/JSON）加载客户端配置，未配置的项回退到环境变量与默认值
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v3"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	wxhelper_sdk "wxhelper-sdk"
+	"wxhelper-sdk/inner"
+	"wxhelper-sdk/logging"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported config format")
+	ErrInvalidConfig     = errors.New("invalid config")
+)
+
+// Format 配置文件格式
+type Format string
+
+const (
+	FormatYAML Format = "yaml"
+	FormatTOML Format = "toml"
+	FormatJSON Format = "json"
+)
+
+// Config 客户端配置，零值字段不覆盖环境变量与默认值
+type Config struct {
+	API        APIConfig       `json:"api" yaml:"api" toml:"api"`
+	Hook       HookConfig      `json:"hook" yaml:"hook" toml:"hook"`
+	Cache      CacheConfig     `json:"cache" yaml:"cache" toml:"cache"`
+	Log        LogConfig       `json:"log" yaml:"log" toml:"log"`
+	RateLimit  RateLimitConfig `json:"rate_limit" yaml:"rate_limit" toml:"rate_limit"`
+	BufferSize int             `json:"buffer_size" yaml:"buffer_size" toml:"buffer_size"` // 消息缓冲区大小
+}
+
+type APIConfig struct {
+	BaseURL     string   `json:"base_url" yaml:"base_url" toml:"base_url"`             // wxhelper http api 地址，如 http://127.0.0.1:19088
+	Timeout     Duration `json:"timeout" yaml:"timeout" toml:"timeout"`                // 单次调用的超时
+	MaxAttempts int      `json:"max_attempts" yaml:"max_attempts" toml:"max_attempts"` // 幂等接口最多尝试次数，1 表示不重试
+}
+
+type HookConfig struct {
+	Mode       string   `json:"mode" yaml:"mode" toml:"mode"`                      // tcp(默认) 或 http
+	ListenAddr string   `json:"listen_addr" yaml:"listen_addr" toml:"listen_addr"` // 本地监听端口，如 19099
+	TCPURL     string   `json:"tcp_url" yaml:"tcp_url" toml:"tcp_url"`             // tcp 模式下 wxhelper 推送消息的地址 host:port
+	HTTPURL    string   `json:"http_url" yaml:"http_url" toml:"http_url"`          // http 模式下 wxhelper 推送消息的地址
+	Timeout    Duration `json:"timeout" yaml:"timeout" toml:"timeout"`             // http 模式下推送超时
+}
+
+type CacheConfig struct {
+	Dir       string   `json:"dir" yaml:"dir" toml:"dir"`                   // 缓存根目录
+	Retention Duration `json:"retention" yaml:"retention" toml:"retention"` // 缓存保留时间，0 表示不清理
+}
+
+type LogConfig struct {
+	Level  string `json:"level" yaml:"level" toml:"level"`    // debug、info、warn、error
+	Format string `json:"format" yaml:"format" toml:"format"` // console(默认) 或 json
+}
+
+// RateLimitConfig 发送限流配置，配置后 Client 的 Send*、Reply* 经由发送队列（WithSendQueue）按会话限流
+type RateLimitConfig struct {
+	Rate  float64 `json:"rate" yaml:"rate" toml:"rate"`    // 每个会话每秒发送的消息数，0 表示不限流
+	Burst int     `json:"burst" yaml:"burst" toml:"burst"` // 突发数量
+}
+
+// Duration 支持 "30s"、"1h30m" 形式的时长
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Load 加载配置文件，格式由扩展名（.yaml/.yml、.toml、.json）决定，加载后校验
+func Load(path string) (*Config, error) {
+	format, err := formatOf(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := Parse(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("load %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Parse 解析并校验配置，未知字段视为错误
+func Parse(data []byte, format Format) (*Config, error) {
+	var cfg Config
+	switch format {
+	case FormatYAML:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+	case FormatTOML:
+		if err := decodeTOML(data, &cfg); err != nil {
+			return nil, err
+		}
+	case FormatJSON:
+		if err := decodeJSON(data, &cfg); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func decodeJSON(data []byte, cfg *Config) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(cfg)
+}
+
+func decodeTOML(data []byte, cfg *Config) error {
+	meta, err := toml.NewDecoder(bytes.NewReader(data)).Decode(cfg)
+	if err != nil {
+		return err
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		return fmt.Errorf("unknown field %q", undecoded[0].String())
+	}
+	return nil
+}
+
+func formatOf(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	case ".toml":
+		return FormatTOML, nil
+	case ".json":
+		return FormatJSON, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedFormat, path)
+}
+
+// Validate 校验配置，返回所有不合法的字段
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(field string, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: %s: %s", ErrInvalidConfig, field, fmt.Sprintf(format, args...)))
+	}
+	if c.API.BaseURL != "" {
+		if u, err := url.Parse(c.API.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			invalid("api.base_url", "want http(s)://host:port, got %q", c.API.BaseURL)
+		}
+	}
+	if c.API.Timeout < 0 {
+		invalid("api.timeout", "must not be negative")
+	}
+	if c.API.MaxAttempts < 0 {
+		invalid("api.max_attempts", "must not be negative")
+	}
+	switch c.Hook.Mode {
+	case "", wxhelper_sdk.HookModeTCP, wxhelper_sdk.HookModeHTTP:
+	default:
+		invalid("hook.mode", "want %q or %q, got %q", wxhelper_sdk.HookModeTCP, wxhelper_sdk.HookModeHTTP, c.Hook.Mode)
+	}
+	if c.Hook.ListenAddr != "" {
+		if port, err := strconv.Atoi(c.Hook.ListenAddr); err != nil || port < 0 || port > 65535 {
+			invalid("hook.listen_addr", "want port, got %q", c.Hook.ListenAddr)
+		}
+	}
+	if c.Hook.TCPURL != "" {
+		if _, _, err := inner.SplitHookURL(c.Hook.TCPURL); err != nil {
+			errs = append(errs, fmt.Errorf("%w: hook.tcp_url: %w", ErrInvalidConfig, err))
+		}
+	}
+	if c.Hook.HTTPURL != "" {
+		if u, err := url.Parse(c.Hook.HTTPURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+			invalid("hook.http_url", "want http(s)://host:port/path, got %q", c.Hook.HTTPURL)
+		}
+	}
+	if c.Hook.Timeout < 0 {
+		invalid("hook.timeout", "must not be negative")
+	}
+	if c.Cache.Retention < 0 {
+		invalid("cache.retention", "must not be negative")
+	}
+	if c.Log.Level != "" {
+		if _, err := zerolog.ParseLevel(c.Log.Level); err != nil {
+			invalid("log.level", "unknown level %q", c.Log.Level)
+		}
+	}
+	switch c.Log.Format {
+	case "", "console", "json":
+	default:
+		invalid("log.format", "want \"console\" or \"json\", got %q", c.Log.Format)
+	}
+	if c.RateLimit.Rate < 0 {
+		invalid("rate_limit.rate", "must not be negative")
+	}
+	if c.RateLimit.Rate > 0 && c.RateLimit.Burst < 1 {
+		invalid("rate_limit.burst", "must be at least 1 when rate is set")
+	}
+	if c.BufferSize < 0 {
+		invalid("buffer_size", "must not be negative")
+	}
+	return errors.Join(errs...)
+}
+
+// Options 转换为 NewClient 的配置项，仅包含已配置的字段
+func (c *Config) Options() []wxhelper_sdk.Option {
+	var opts []wxhelper_sdk.Option
+	if c.API.BaseURL != "" {
+		opts = append(opts, wxhelper_sdk.WithAPIBaseURL(c.API.BaseURL))
+	}
+	if c.API.Timeout > 0 {
+		opts = append(opts, wxhelper_sdk.WithTimeout(time.Duration(c.API.Timeout)))
+	}
+	if c.API.MaxAttempts > 0 {
+		policy := wxhelper_sdk.DefaultRetryPolicy
+		policy.MaxAttempts = c.API.MaxAttempts
+		opts = append(opts, wxhelper_sdk.WithRetryPolicy(policy))
+	}
+	if c.Hook.ListenAddr != "" {
+		opts = append(opts, wxhelper_sdk.WithListenAddr(c.Hook.ListenAddr))
+	}
+	if c.Hook.TCPURL != "" {
+		opts = append(opts, wxhelper_sdk.WithHookURL(c.Hook.TCPURL))
+	}
+	if c.Hook.Mode == wxhelper_sdk.HookModeHTTP {
+		timeout := time.Duration(c.Hook.Timeout)
+		if timeout == 0 {
+			timeout = wxhelper_sdk.DefaultHTTPHookTimeout
+		}
+		opts = append(opts, wxhelper_sdk.WithHTTPHook(c.Hook.HTTPURL, timeout))
+	}
+	if c.Cache.Dir != "" {
+		opts = append(opts, wxhelper_sdk.WithCacheDir(c.Cache.Dir))
+	}
+	if c.Cache.Retention > 0 {
+		opts = append(opts, wxhelper_sdk.WithCacheRetention(time.Duration(c.Cache.Retention)))
+	}
+	if c.Log.Level != "" || c.Log.Format != "" {
+		opts = append(opts, wxhelper_sdk.WithLogger(c.Log.Logger()))
+	}
+	if c.BufferSize > 0 {
+		opts = append(opts, wxhelper_sdk.WithBufferSize(c.BufferSize))
+	}
+	if queue, ok := c.RateLimit.SendQueue(); ok {
+		opts = append(opts, wxhelper_sdk.WithSendQueue(queue))
+	}
+	return opts
+}
+
+// Logger 按配置创建日志
+func (l LogConfig) Logger() zerolog.Logger {
+	var logger zerolog.Logger
+	if l.Format == "json" {
+		logger = logging.New(os.Stderr)
+	} else {
+		logger = logging.New(zerolog.ConsoleWriter{Out: os.Stderr})
+	}
+	if level, err := zerolog.ParseLevel(l.Level); err == nil && l.Level != "" {
+		logger = logger.Level(level)
+	}
+	return logger
+}
+
+// SendQueue 按配置创建发送队列配置，未配置限流时返回 false
+func (r RateLimitConfig) SendQueue() (wxhelper_sdk.SendQueueConfig, bool) {
+	if r.Rate <= 0 {
+		return wxhelper_sdk.SendQueueConfig{}, false
+	}
+	return wxhelper_sdk.SendQueueConfig{
+		ConversationRate:  r.Rate,
+		ConversationBurst: r.Burst,
+		Size:              wxhelper_sdk.DefaultSendQueueConfig.Size,
+	}, true
+}
+
+// NewClient 按配置创建客户端，opts 优先于配置文件
+func (c *Config) NewClient(opts ...wxhelper_sdk.Option) (*wxhelper_sdk.Client, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return wxhelper_sdk.NewClient(append(c.Options(), opts...)...), nil
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"wxhelper-sdk/inner"
+)
+
+func TestLoad(t *testing.T) {
+	want := Config{
+		API: APIConfig{BaseURL: "http://127.0.0.1:19088", Timeout: Duration(5 * time.Second), MaxAttempts: 4},
+		Hook: HookConfig{
+			Mode:       "http",
+			ListenAddr: "19099",
+			TCPURL:     "127.0.0.1:19089",
+			HTTPURL:    "http://10.0.0.2:19099/hook",
+			Timeout:    Duration(15 * time.Second),
+		},
+		Cache:      CacheConfig{Dir: "/tmp/wxhelper", Retention: Duration(72 * time.Hour)},
+		Log:        LogConfig{Level: "debug", Format: "json"},
+		RateLimit:  RateLimitConfig{Rate: 0.5, Burst: 3},
+		BufferSize: 200,
+	}
+	for _, path := range []string{"testdata/client.yaml", "testdata/client.toml", "testdata/client.json"} {
+		t.Run(path, func(t *testing.T) {
+			cfg, err := Load(path)
+			if assert.Nil(t, err) {
+				assert.Equal(t, want, *cfg)
+			}
+		})
+	}
+}
+
+func TestLoad_UnsupportedFormat(t *testing.T) {
+	_, err := Load("testdata/client.ini")
+	assert.True(t, errors.Is(err, ErrUnsupportedFormat))
+}
+
+func TestParse_UnknownField(t *testing.T) {
+	_, err := Parse([]byte("api:\n  base_ur: http://127.0.0.1:19088\n"), FormatYAML)
+	assert.NotNil(t, err)
+	_, err = Parse([]byte("[api]\nbase_ur = \"http://127.0.0.1:19088\"\n"), FormatTOML)
+	assert.ErrorContains(t, err, "api.base_ur")
+	_, err = Parse([]byte(`{"buffer": 1}`), FormatJSON)
+	assert.NotNil(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		field  string
+	}{
+		{"hook url without port", "hook:\n  tcp_url: 127.0.0.1\n", "hook.tcp_url"},
+		{"hook url bad port", "hook:\n  tcp_url: 127.0.0.1:port\n", "hook.tcp_url"},
+		{"hook url empty host", "hook:\n  tcp_url: :19089\n", "hook.tcp_url"},
+		{"base url without scheme", "api:\n  base_url: 127.0.0.1:19088\n", "api.base_url"},
+		{"unknown mode", "hook:\n  mode: udp\n", "hook.mode"},
+		{"http url without scheme", "hook:\n  http_url: 10.0.0.2:19099\n", "hook.http_url"},
+		{"listen addr", "hook:\n  listen_addr: 127.0.0.1:19099\n", "hook.listen_addr"},
+		{"log level", "log:\n  level: verbose\n", "log.level"},
+		{"rate limit burst", "rate_limit:\n  rate: 1\n", "rate_limit.burst"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.config), FormatYAML)
+			assert.True(t, errors.Is(err, ErrInvalidConfig))
+			assert.ErrorContains(t, err, tt.field)
+		})
+	}
+
+	_, err := Parse([]byte("hook:\n  tcp_url: 127.0.0.1\n"), FormatYAML)
+	assert.True(t, errors.Is(err, inner.ErrInvalidHookURL))
+}
+
+func TestConfig_Empty(t *testing.T) {
+	cfg, err := Parse(nil, FormatYAML)
+	if assert.Nil(t, err) {
+		assert.Empty(t, cfg.Options())
+		_, ok := cfg.RateLimit.SendQueue()
+		assert.False(t, ok)
+	}
+}
+
+func TestConfig_NewClient(t *testing.T) {
+	cfg, err := Load("testdata/client.yaml")
+	if !assert.Nil(t, err) {
+		return
+	}
+	cfg.Cache.Dir = t.TempDir()
+	client, err := cfg.NewClient()
+	assert.Nil(t, err)
+	assert.NotNil(t, client)
+	queue, ok := cfg.RateLimit.SendQueue()
+	if assert.True(t, ok) {
+		assert.Equal(t, 0.5, queue.ConversationRate)
+		assert.Equal(t, 3, queue.ConversationBurst)
+	}
+	// 配置限流时额外添加 WithSendQueue
+	withQueue := len(cfg.Options())
+	cfg.RateLimit = RateLimitConfig{}
+	assert.Len(t, cfg.Options(), withQueue-1)
+
+	cfg.Hook.TCPURL = "127.0.0.1"
+	_, err = cfg.NewClient()
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+}
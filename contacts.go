@@ -0,0 +1,422 @@
+// Package wxhelper_sdk
+// @Author Clover
+// @Data 2025/1/21 上午9:30:00
+// @Desc 联系人目录：本地缓存、索引与模糊搜索
+package wxhelper_sdk
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"wxhelper-sdk/inner"
+	"wxhelper-sdk/inner/models"
+	"wxhelper-sdk/logging"
+)
+
+const DefaultContactRefresh = 30 * time.Minute
+
+var (
+	ErrContactNotFound  = errors.New("contact not found")
+	ErrAmbiguousContact = errors.New("contact name is ambiguous")
+)
+
+// ContactKind 联系人类别
+type ContactKind int
+
+const (
+	ContactOther    ContactKind = iota // 非好友（群成员、陌生人）及系统账号
+	ContactFriend                      // 好友
+	ContactGroup                       // 群聊
+	ContactOfficial                    // 公众号、服务号
+)
+
+func (k ContactKind) String() string {
+	switch k {
+	case ContactFriend:
+		return "Friend"
+	case ContactGroup:
+		return "Group"
+	case ContactOfficial:
+		return "Official"
+	}
+	return "Other"
+}
+
+// systemWxids 微信内置的功能账号
+var systemWxids = map[string]bool{
+	"filehelper": true, "fmessage": true, "floatbottle": true, "medianote": true, "newsapp": true,
+	"qmessage": true, "qqmail": true, "tmessage": true, "weixin": true, "notifymessage": true,
+}
+
+// Contact 联系人
+type Contact struct {
+	Wxid          string      `json:"wxid"`
+	CustomAccount string      `json:"customAccount"` // 微信号
+	Nickname      string      `json:"nickname"`
+	Remark        string      `json:"remark"`
+	Pinyin        string      `json:"pinyin"`    // 昵称拼音首字母
+	PinyinAll     string      `json:"pinyinAll"` // 昵称全拼
+	RemarkPinyin  string      `json:"remarkPinyin"`
+	Labels        []string    `json:"labels"` // 标签 id
+	Type          int         `json:"type"`
+	VerifyFlag    int         `json:"verifyFlag"`
+	Kind          ContactKind `json:"kind"`
+}
+
+func newContact(u *models.User) *Contact {
+	c := &Contact{
+		Wxid:          u.Wxid,
+		CustomAccount: u.CustomAccount,
+		Nickname:      u.Nickname,
+		Remark:        u.Remark,
+		Pinyin:        u.Pinyin,
+		PinyinAll:     u.PinyinAll,
+		RemarkPinyin:  u.RemarkPinyin,
+		Type:          u.Type,
+		VerifyFlag:    u.VerifyFlag,
+	}
+	for _, label := range strings.Split(u.LabelIds, ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			c.Labels = append(c.Labels, label)
+		}
+	}
+	c.Kind = classifyContact(u)
+	return c
+}
+
+// classifyContact 按 wxid、VerifyFlag 与 Type 区分群聊、公众号与好友
+func classifyContact(u *models.User) ContactKind {
+	switch {
+	case strings.HasSuffix(u.Wxid, "@chatroom"):
+		return ContactGroup
+	case u.VerifyFlag != 0 || strings.HasPrefix(u.Wxid, "gh_"):
+		return ContactOfficial
+	case systemWxids[u.Wxid]:
+		return ContactOther
+	case u.Type&1 != 0: // 通讯录中的联系人
+		return ContactFriend
+	}
+	return ContactOther
+}
+
+// DisplayName 备注优先，其次昵称、wxid
+func (c *Contact) DisplayName() string {
+	switch {
+	case c.Remark != "":
+		return c.Remark
+	case c.Nickname != "":
+		return c.Nickname
+	}
+	return c.Wxid
+}
+
+// HasLabel 是否带有标签
+func (c *Contact) HasLabel(labelID string) bool {
+	return slices.Contains(c.Labels, labelID)
+}
+
+// names 参与索引与搜索的名称，按权重从高到低排列
+func (c *Contact) names() []string {
+	return []string{c.Remark, c.Nickname, c.CustomAccount, c.Wxid, c.RemarkPinyin, c.PinyinAll, c.Pinyin}
+}
+
+// normalizeName 忽略大小写与空白
+func normalizeName(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), "")
+}
+
+// contactIndex 某次刷新得到的联系人快照，创建后只读
+type contactIndex struct {
+	all       []*Contact
+	byWxid    map[string]*Contact
+	byName    map[string][]*Contact // 备注、昵称、微信号及拼音
+	byLabel   map[string][]*Contact
+	updatedAt time.Time
+}
+
+func newContactIndex(members models.Members) *contactIndex {
+	idx := &contactIndex{
+		byWxid:    make(map[string]*Contact, len(members)),
+		byName:    make(map[string][]*Contact),
+		byLabel:   make(map[string][]*Contact),
+		updatedAt: time.Now(),
+	}
+	for _, u := range members {
+		if u == nil || u.Wxid == "" || idx.byWxid[u.Wxid] != nil {
+			continue
+		}
+		contact := newContact(u)
+		idx.all = append(idx.all, contact)
+		idx.byWxid[contact.Wxid] = contact
+		seen := make(map[string]bool)
+		for _, name := range contact.names() {
+			if name != contact.Wxid { // wxid 由 byWxid 索引
+				idx.addName(seen, name, contact)
+			}
+		}
+		for _, label := range contact.Labels {
+			idx.byLabel[label] = append(idx.byLabel[label], contact)
+		}
+	}
+	return idx
+}
+
+func (idx *contactIndex) addName(seen map[string]bool, name string, contact *Contact) {
+	key := normalizeName(name)
+	if key == "" || seen[key] {
+		return
+	}
+	seen[key] = true
+	idx.byName[key] = append(idx.byName[key], contact)
+}
+
+// Contacts 联系人目录，登录后及按间隔从 wxhelper 刷新，查询只读取本地缓存
+type Contacts struct {
+	wxClient *inner.WxClient
+	log      *logging.FieldLogger
+	interval time.Duration // 定期刷新间隔，0 表示仅在登录时刷新
+
+	mu      sync.Mutex // 串行化刷新
+	index   atomic.Pointer[contactIndex]
+	refresh chan struct{} // 登录时通知后台刷新
+}
+
+func newContacts(wxClient *inner.WxClient, log *logging.FieldLogger, interval time.Duration) *Contacts {
+	return &Contacts{wxClient: wxClient, log: log, interval: interval, refresh: make(chan struct{}, 1)}
+}
+
+// Contacts 联系人目录
+func (c *Client) Contacts() *Contacts {
+	return c.contacts
+}
+
+// Refresh 从 wxhelper 重新拉取联系人并重建索引，失败时保留原有数据
+func (d *Contacts) Refresh(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	members, err := d.wxClient.GetContactList(ctx)
+	if err != nil {
+		return fmt.Errorf("get contact list err: %w", err)
+	}
+	idx := newContactIndex(members)
+	d.index.Store(idx)
+	d.log.Debug("contacts refreshed", map[string]interface{}{"count": len(idx.all)})
+	return nil
+}
+
+// Load 尚未加载过联系人时刷新一次
+func (d *Contacts) Load(ctx context.Context) error {
+	if d.index.Load() != nil {
+		return nil
+	}
+	return d.Refresh(ctx)
+}
+
+// UpdatedAt 最近一次刷新成功的时间，未加载时为零值
+func (d *Contacts) UpdatedAt() time.Time {
+	if idx := d.index.Load(); idx != nil {
+		return idx.updatedAt
+	}
+	return time.Time{}
+}
+
+func (d *Contacts) snapshot() *contactIndex {
+	if idx := d.index.Load(); idx != nil {
+		return idx
+	}
+	return &contactIndex{}
+}
+
+// notifyRefresh 通知后台刷新，不阻塞
+func (d *Contacts) notifyRefresh() {
+	select {
+	case d.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// run 登录时及按间隔刷新，直到 ctx 结束
+func (d *Contacts) run(ctx context.Context) {
+	var tick <-chan time.Time
+	if d.interval > 0 {
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.refresh:
+		case <-tick:
+		}
+		if err := d.Refresh(ctx); err != nil && ctx.Err() == nil {
+			d.log.WarnWithErr(err, "refresh contacts error")
+		}
+	}
+}
+
+// Get 按 wxid 查找
+func (d *Contacts) Get(wxid string) (*Contact, bool) {
+	contact, ok := d.snapshot().byWxid[wxid]
+	return contact, ok
+}
+
+// All 全部联系人，包括群聊与公众号
+func (d *Contacts) All() []*Contact {
+	return slices.Clone(d.snapshot().all)
+}
+
+// Friends 好友
+func (d *Contacts) Friends() []*Contact {
+	return d.byKind(ContactFriend)
+}
+
+// Groups 通讯录中的群聊
+func (d *Contacts) Groups() []*Contact {
+	return d.byKind(ContactGroup)
+}
+
+// Officials 公众号
+func (d *Contacts) Officials() []*Contact {
+	return d.byKind(ContactOfficial)
+}
+
+func (d *Contacts) byKind(kind ContactKind) []*Contact {
+	var contacts []*Contact
+	for _, contact := range d.snapshot().all {
+		if contact.Kind == kind {
+			contacts = append(contacts, contact)
+		}
+	}
+	return contacts
+}
+
+// WithLabel 带有标签的联系人
+func (d *Contacts) WithLabel(labelID string) []*Contact {
+	return slices.Clone(d.snapshot().byLabel[labelID])
+}
+
+// Find 备注、昵称、微信号或拼音与 name 相同（忽略大小写与空白）的联系人
+func (d *Contacts) Find(name string) []*Contact {
+	return slices.Clone(d.snapshot().byName[normalizeName(name)])
+}
+
+// matchScore name 与 query 的匹配程度，均已 normalize，0 表示不匹配
+func matchScore(name, query string) int {
+	switch {
+	case name == "":
+		return 0
+	case name == query:
+		return 400
+	case strings.HasPrefix(name, query):
+		return 300
+	case strings.Contains(name, query):
+		return 200
+	case isSubsequence(name, query):
+		return 100
+	}
+	return 0
+}
+
+// isSubsequence query 的字符是否按顺序出现在 s 中，如 "zs" 匹配 "zhangsan"
+func isSubsequence(s, query string) bool {
+	runes := []rune(query)
+	for _, r := range s {
+		if len(runes) > 0 && r == runes[0] {
+			runes = runes[1:]
+		}
+	}
+	return len(runes) == 0
+}
+
+// contactHit 模糊搜索命中的联系人及匹配程度
+type contactHit struct {
+	contact *Contact
+	score   int
+}
+
+// tier 匹配档次（完全相同、前缀、包含、按序包含），不含字段先后的微调
+func (h contactHit) tier() int {
+	return (h.score + 99) / 100
+}
+
+// search 模糊搜索，结果按匹配程度排序，好友优先
+func (d *Contacts) search(query string) []contactHit {
+	query = normalizeName(query)
+	if query == "" {
+		return nil
+	}
+	var hits []contactHit
+	for _, contact := range d.snapshot().all {
+		best := 0
+		for i, name := range contact.names() {
+			if score := matchScore(normalizeName(name), query); score > 0 {
+				best = max(best, score-i) // 同等匹配程度下备注优先于昵称、拼音
+			}
+		}
+		if best > 0 {
+			hits = append(hits, contactHit{contact: contact, score: best})
+		}
+	}
+	slices.SortStableFunc(hits, func(a, b contactHit) int {
+		if c := cmp.Compare(b.score, a.score); c != 0 {
+			return c
+		}
+		if (a.contact.Kind == ContactFriend) != (b.contact.Kind == ContactFriend) {
+			if a.contact.Kind == ContactFriend {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.contact.Wxid, b.contact.Wxid)
+	})
+	return hits
+}
+
+// Search 模糊搜索：依次按完全相同、前缀、包含与按序包含匹配备注、昵称、微信号、wxid 及拼音
+// 结果按匹配程度排序，好友优先，limit <= 0 表示不限制数量
+func (d *Contacts) Search(query string, limit int) []*Contact {
+	hits := d.search(query)
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	contacts := make([]*Contact, len(hits))
+	for i, h := range hits {
+		contacts[i] = h.contact
+	}
+	return contacts
+}
+
+// Resolve 将 wxid、备注、昵称或微信号解析为联系人，如 "Alice" -> wxid_xxx，尚未加载时先刷新
+// 依次尝试 wxid、完全匹配与模糊搜索，模糊搜索时匹配档次最高的联系人唯一才返回（备注、昵称等字段的先后不参与比较），否则返回 ErrAmbiguousContact
+func (d *Contacts) Resolve(ctx context.Context, name string) (*Contact, error) {
+	if err := d.Load(ctx); err != nil {
+		return nil, err
+	}
+	if contact, ok := d.Get(name); ok {
+		return contact, nil
+	}
+	candidates := d.Find(name)
+	switch len(candidates) {
+	case 0:
+	case 1:
+		return candidates[0], nil
+	default:
+		return nil, fmt.Errorf("%w: %q matches %s, %s...", ErrAmbiguousContact, name, candidates[0].Wxid, candidates[1].Wxid)
+	}
+	hits := d.search(name)
+	switch {
+	case len(hits) == 0:
+		return nil, fmt.Errorf("%w: %q", ErrContactNotFound, name)
+	case len(hits) == 1 || hits[0].tier() > hits[1].tier():
+		return hits[0].contact, nil
+	}
+	return nil, fmt.Errorf("%w: %q matches %s, %s...", ErrAmbiguousContact, name, hits[0].contact.Wxid, hits[1].contact.Wxid)
+}
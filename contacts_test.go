@@ -0,0 +1,135 @@
+package wxhelper_sdk
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+const contactListJSON = `{"code":1,"msg":"success","data":[
+{"type":3,"verifyFlag":0,"customAccount":"alice_w","nickname":"Alice","pinyin":"ALICE","pinyinAll":"alice","remark":"爱丽丝","remarkPinyin":"ailisi","labelIds":"1,2","wxid":"wxid_alice"},
+{"type":3,"verifyFlag":0,"customAccount":"","nickname":"张三","pinyin":"ZS","pinyinAll":"zhangsan","remark":"","remarkPinyin":"","labelIds":"2","wxid":"wxid_zhangsan"},
+{"type":0,"verifyFlag":0,"customAccount":"","nickname":"Alice","pinyin":"ALICE","pinyinAll":"alice","remark":"","remarkPinyin":"","labelIds":"","wxid":"wxid_alice2"},
+{"type":2,"verifyFlag":0,"customAccount":"","nickname":"测试群","pinyin":"CSQ","pinyinAll":"ceshiqun","remark":"","remarkPinyin":"","labelIds":"","wxid":"123@chatroom"},
+{"type":3,"verifyFlag":24,"customAccount":"","nickname":"微信团队","pinyin":"WXTD","pinyinAll":"weixintuandui","remark":"","remarkPinyin":"","labelIds":"","wxid":"gh_3dfda90e39d6"},
+{"type":3,"verifyFlag":0,"customAccount":"","nickname":"文件传输助手","pinyin":"WJCSZS","pinyinAll":"wenjianchuanshuzhushou","remark":"","remarkPinyin":"","labelIds":"","wxid":"filehelper"}
+]}`
+
+// contactServer 返回联系人列表，并记录请求次数
+type contactServer struct {
+	requests atomic.Int32
+}
+
+func (f *contactServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.requests.Add(1)
+	_, _ = w.Write([]byte(contactListJSON))
+}
+
+func wxids(contacts []*Contact) []string {
+	ids := make([]string, len(contacts))
+	for i, contact := range contacts {
+		ids[i] = contact.Wxid
+	}
+	return ids
+}
+
+func TestContacts_Index(t *testing.T) {
+	fake := &contactServer{}
+	client := newTestClient(t, fake)
+	contacts := client.Contacts()
+	_, ok := contacts.Get("wxid_alice")
+	assert.False(t, ok) // 未加载
+	assert.True(t, contacts.UpdatedAt().IsZero())
+
+	assert.Nil(t, contacts.Load(context.Background()))
+	assert.Nil(t, contacts.Load(context.Background()))
+	assert.Equal(t, int32(1), fake.requests.Load())
+
+	alice, ok := contacts.Get("wxid_alice")
+	if assert.True(t, ok) {
+		assert.Equal(t, "爱丽丝", alice.DisplayName())
+		assert.Equal(t, []string{"1", "2"}, alice.Labels)
+		assert.True(t, alice.HasLabel("1"))
+	}
+	assert.Len(t, contacts.All(), 6)
+	assert.Equal(t, []string{"wxid_alice", "wxid_zhangsan"}, wxids(contacts.Friends()))
+	assert.Equal(t, []string{"123@chatroom"}, wxids(contacts.Groups()))
+	assert.Equal(t, []string{"gh_3dfda90e39d6"}, wxids(contacts.Officials()))
+	assert.Equal(t, []string{"wxid_alice", "wxid_zhangsan"}, wxids(contacts.WithLabel("2")))
+
+	assert.Equal(t, []string{"wxid_alice"}, wxids(contacts.Find("alice_w")))
+	assert.Equal(t, []string{"wxid_alice"}, wxids(contacts.Find("爱丽丝")))
+	assert.Equal(t, []string{"wxid_zhangsan"}, wxids(contacts.Find("ZhangSan")))
+	assert.Equal(t, []string{"wxid_alice", "wxid_alice2"}, wxids(contacts.Find(" ALICE ")))
+}
+
+func TestContacts_Search(t *testing.T) {
+	client := newTestClient(t, &contactServer{})
+	contacts := client.Contacts()
+	assert.Nil(t, contacts.Refresh(context.Background()))
+
+	assert.Equal(t, []string{"wxid_alice", "wxid_alice2"}, wxids(contacts.Search("ali", 0))) // 好友优先
+	assert.Equal(t, []string{"wxid_alice"}, wxids(contacts.Search("ali", 1)))
+	assert.Equal(t, "wxid_zhangsan", contacts.Search("zhs", 0)[0].Wxid) // 拼音按序包含，首字母更靠前
+	assert.Equal(t, []string{"123@chatroom"}, wxids(contacts.Search("测试", 0)))
+	assert.Empty(t, contacts.Search("  ", 0))
+	assert.Empty(t, contacts.Search("bob", 0))
+}
+
+func TestContacts_Resolve(t *testing.T) {
+	client := newTestClient(t, &contactServer{})
+	contacts := client.Contacts()
+	ctx := context.Background()
+
+	contact, err := contacts.Resolve(ctx, "爱丽丝") // 尚未加载时先刷新
+	if assert.Nil(t, err) {
+		assert.Equal(t, "wxid_alice", contact.Wxid)
+	}
+	contact, err = contacts.Resolve(ctx, "wxid_alice2")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "wxid_alice2", contact.Wxid)
+	}
+	contact, err = contacts.Resolve(ctx, "zhang")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "wxid_zhangsan", contact.Wxid)
+	}
+	_, err = contacts.Resolve(ctx, "Alice")
+	assert.True(t, errors.Is(err, ErrAmbiguousContact))
+	_, err = contacts.Resolve(ctx, "bob")
+	assert.True(t, errors.Is(err, ErrContactNotFound))
+}
+
+func TestContacts_ResolveBestMatch(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":1,"msg":"success","data":[
+{"type":3,"nickname":"Alice","pinyinAll":"alice","wxid":"wxid_alice"},
+{"type":3,"nickname":"Malice","pinyinAll":"malice","wxid":"wxid_malice"}
+]}`))
+	}))
+	contacts := client.Contacts()
+	ctx := context.Background()
+
+	// 前缀匹配高于包含匹配，唯一的最佳匹配直接返回
+	contact, err := contacts.Resolve(ctx, "ali")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "wxid_alice", contact.Wxid)
+	}
+	assert.Len(t, contacts.Search("ali", 0), 2)
+	// 匹配程度相同时无法确定
+	_, err = contacts.Resolve(ctx, "lice")
+	assert.True(t, errors.Is(err, ErrAmbiguousContact))
+
+	client = newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":1,"msg":"success","data":[
+{"type":3,"nickname":"A","remark":"Alice","wxid":"wxid_alice"},
+{"type":3,"nickname":"Alicia","wxid":"wxid_alicia"}
+]}`))
+	}))
+
+	// 备注与昵称均为前缀匹配，字段先后不影响，视为无法确定
+	_, err = client.Contacts().Resolve(ctx, "ali")
+	assert.True(t, errors.Is(err, ErrAmbiguousContact))
+}
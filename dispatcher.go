@@ -0,0 +1,195 @@
+// Package wxhelper_sdk
+// @Author Clover
+// @Data 2025/1/12 下午8:10:00
+// @Desc 消息分发路由
+package wxhelper_sdk
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Predicate 消息匹配条件
+type Predicate func(message *Message) bool
+
+// Middleware 消息处理中间件
+type Middleware func(next MessageHandler) MessageHandler
+
+// Chain 将中间件按顺序包裹在 handler 外层，第一个中间件最先执行
+func Chain(handler MessageHandler, middlewares ...Middleware) MessageHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+type route struct {
+	predicate Predicate
+	handler   MessageHandler
+}
+
+// Dispatcher 按条件将消息分发给注册的 handler，按注册顺序匹配，仅执行第一个命中的 handler
+type Dispatcher struct {
+	mu          sync.RWMutex
+	routes      []route
+	middlewares []Middleware
+	chain       MessageHandler // 全局中间件包裹的 dispatch，Use 时重建
+	notFound    MessageHandler
+}
+
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{}
+}
+
+// Use 注册全局中间件，作用于所有路由（包括 NotFound）
+func (d *Dispatcher) Use(middlewares ...Middleware) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.middlewares = append(d.middlewares, middlewares...)
+	d.chain = Chain(MessageHandlerFunc(d.dispatch), d.middlewares...)
+}
+
+// Handle 注册路由，middlewares 仅作用于当前路由
+func (d *Dispatcher) Handle(predicate Predicate, handler MessageHandler, middlewares ...Middleware) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.routes = append(d.routes, route{predicate: predicate, handler: Chain(handler, middlewares...)})
+}
+
+// HandleFunc 以函数的方式注册路由
+func (d *Dispatcher) HandleFunc(predicate Predicate, handler func(message *Message) error, middlewares ...Middleware) {
+	d.Handle(predicate, MessageHandlerFunc(handler), middlewares...)
+}
+
+// NotFound 没有路由命中时的 handler
+func (d *Dispatcher) NotFound(handler MessageHandler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.notFound = handler
+}
+
+// HandleMessage 实现 MessageHandler
+func (d *Dispatcher) HandleMessage(message *Message) error {
+	d.mu.RLock()
+	chain := d.chain
+	d.mu.RUnlock()
+	if chain == nil {
+		return d.dispatch(message)
+	}
+	return chain.HandleMessage(message)
+}
+
+func (d *Dispatcher) dispatch(message *Message) error {
+	d.mu.RLock()
+	routes := d.routes
+	notFound := d.notFound
+	d.mu.RUnlock()
+	for _, r := range routes {
+		if r.predicate == nil || r.predicate(message) {
+			return r.handler.HandleMessage(message)
+		}
+	}
+	if notFound != nil {
+		return notFound.HandleMessage(message)
+	}
+	return nil
+}
+
+// MatchType 消息类型匹配
+func MatchType(types ...MsgType) Predicate {
+	return func(message *Message) bool {
+		for _, t := range types {
+			if message.Type == t {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchGroup 群聊消息
+func MatchGroup() Predicate {
+	return func(message *Message) bool {
+		return message.IsGroup()
+	}
+}
+
+// MatchPrivate 私聊消息
+func MatchPrivate() Predicate {
+	return func(message *Message) bool {
+		return !message.IsGroup()
+	}
+}
+
+// MatchSender 发送者匹配（群消息为群内实际发送者）
+func MatchSender(wxids ...string) Predicate {
+	return func(message *Message) bool {
+		sender := message.Sender()
+		for _, wxid := range wxids {
+			if sender == wxid {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchPrefix 消息内容前缀匹配（群消息已去除发送者前缀）
+func MatchPrefix(prefix string) Predicate {
+	return func(message *Message) bool {
+		return strings.HasPrefix(message.Text(), prefix)
+	}
+}
+
+// MatchRegexp 消息内容正则匹配（群消息已去除发送者前缀）
+func MatchRegexp(re *regexp.Regexp) Predicate {
+	return func(message *Message) bool {
+		return re.MatchString(message.Text())
+	}
+}
+
+// MatchAtMe 群聊中@了当前登录账号
+func MatchAtMe() Predicate {
+	return func(message *Message) bool {
+		return message.IsAtMe()
+	}
+}
+
+// MatchAtAll 群聊中@所有人
+func MatchAtAll() Predicate {
+	return func(message *Message) bool {
+		return message.IsAtAll()
+	}
+}
+
+// MatchAll 所有条件均满足
+func MatchAll(predicates ...Predicate) Predicate {
+	return func(message *Message) bool {
+		for _, p := range predicates {
+			if !p(message) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// MatchAny 任一条件满足
+func MatchAny(predicates ...Predicate) Predicate {
+	return func(message *Message) bool {
+		for _, p := range predicates {
+			if p(message) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchNot 条件取反
+func MatchNot(predicate Predicate) Predicate {
+	return func(message *Message) bool {
+		return !predicate(message)
+	}
+}
@@ -0,0 +1,128 @@
+package wxhelper_sdk
+
+import (
+	"bytes"
+	"errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestDispatcher_Route(t *testing.T) {
+	d := NewDispatcher()
+	var hit string
+	d.HandleFunc(MatchAll(MatchGroup(), MatchPrefix("/ping")), func(message *Message) error {
+		hit = "group-ping"
+		return nil
+	})
+	d.HandleFunc(MatchRegexp(regexp.MustCompile(`^hello`)), func(message *Message) error {
+		hit = "hello"
+		return nil
+	})
+	d.HandleFunc(MatchSender("wxid_admin"), func(message *Message) error {
+		hit = "admin"
+		return nil
+	})
+	d.NotFound(MessageHandlerFunc(func(message *Message) error {
+		hit = "notFound"
+		return nil
+	}))
+
+	tests := []struct {
+		name string
+		msg  *Message
+		want string
+	}{
+		{"group prefix", &Message{FromUser: "123@chatroom", Content: "wxid_a:\n/ping"}, "group-ping"},
+		{"private prefix", &Message{FromUser: "wxid_a", Content: "/ping"}, "notFound"},
+		{"regexp", &Message{FromUser: "wxid_a", Content: "hello world"}, "hello"},
+		{"group sender", &Message{FromUser: "123@chatroom", Content: "wxid_admin:\nanything"}, "admin"},
+		{"fallback", &Message{FromUser: "wxid_b", Content: "bye"}, "notFound"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hit = ""
+			assert.Nil(t, d.HandleMessage(tt.msg))
+			assert.Equal(t, tt.want, hit)
+		})
+	}
+}
+
+func TestDispatcher_Middleware(t *testing.T) {
+	d := NewDispatcher()
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next MessageHandler) MessageHandler {
+			return MessageHandlerFunc(func(message *Message) error {
+				order = append(order, name)
+				return next.HandleMessage(message)
+			})
+		}
+	}
+	d.Use(Recover(), trace("global"))
+	d.HandleFunc(MatchType(MsgTypeImage), func(message *Message) error {
+		panic("boom")
+	})
+	d.HandleFunc(nil, func(message *Message) error {
+		order = append(order, "handler")
+		return nil
+	}, trace("route"))
+
+	assert.Nil(t, d.HandleMessage(&Message{Type: 1}))
+	assert.Equal(t, []string{"global", "route", "handler"}, order)
+
+	err := d.HandleMessage(&Message{Type: MsgTypeImage})
+	assert.True(t, errors.Is(err, ErrHandlerPanic))
+
+	// 中间件链仅在 Use 时构建，不随每条消息重建
+	var built int
+	d.Use(func(next MessageHandler) MessageHandler {
+		built++
+		return next
+	})
+	for i := 0; i < 3; i++ {
+		_ = d.HandleMessage(&Message{Type: 1})
+	}
+	assert.Equal(t, 1, built)
+}
+
+func TestMiddleware_ClientLogger(t *testing.T) {
+	var buf bytes.Buffer
+	client := NewClient(WithCacheDir(t.TempDir()), WithLogger(zerolog.New(&buf).With().Str("account", "a").Logger()))
+	defer client.stop()
+	handler := Chain(MessageHandlerFunc(func(message *Message) error {
+		panic("boom")
+	}), Logging(), Recover())
+
+	// 经客户端接收的消息使用客户端的日志
+	err := handler.HandleMessage(&Message{MsgId: 42, client: client})
+	assert.True(t, errors.Is(err, ErrHandlerPanic))
+	assert.Contains(t, buf.String(), "message handler panic")
+	assert.Contains(t, buf.String(), "handle message failed")
+	assert.Contains(t, buf.String(), `"account":"a"`)
+}
+
+func TestRateLimit(t *testing.T) {
+	h := Chain(MessageHandlerFunc(func(message *Message) error { return nil }), RateLimit(0.001, 2))
+	msg := &Message{FromUser: "wxid_a"}
+	assert.Nil(t, h.HandleMessage(msg))
+	assert.Nil(t, h.HandleMessage(msg))
+	assert.Equal(t, ErrRateLimited, h.HandleMessage(msg))
+	// 不同会话独立计数
+	assert.Nil(t, h.HandleMessage(&Message{FromUser: "wxid_b"}))
+}
+
+func TestKeyedLimiter_Evict(t *testing.T) {
+	l := newKeyedLimiter(10, 1) // 补满需 100ms，闲置 100ms 后回收
+	assert.True(t, l.allow("wxid_a"))
+	time.Sleep(120 * time.Millisecond)
+	// wxid_a 刚补满，未闲置足够长
+	assert.True(t, l.allow("wxid_b"))
+	assert.Equal(t, 2, l.len())
+
+	time.Sleep(260 * time.Millisecond)
+	assert.True(t, l.allow("wxid_c"))
+	assert.Equal(t, 1, l.len())
+}
@@ -0,0 +1,139 @@
+package wxhelper_sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"wxhelper-sdk/inner"
+)
+
+// endpointServer 记录最近一次请求的路径与请求体，以 code 作为返回码
+type endpointServer struct {
+	path string
+	body map[string]any
+	code int
+}
+
+func (s *endpointServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.path, s.body = r.URL.Path, nil
+	_ = json.NewDecoder(r.Body).Decode(&s.body)
+	_, _ = fmt.Fprintf(w, `{"code":%d,"msg":"success","data":"识别结果"}`, s.code)
+}
+
+func TestWxClient_Endpoints(t *testing.T) {
+	fake := &endpointServer{}
+	wx := newTestClient(t, fake, WithRetryPolicy(RetryPolicy{})).wxClient
+
+	tests := []struct {
+		endpoint string
+		success  int // 成功的返回码
+		failure  int // 失败的返回码
+		call     func(ctx context.Context) error
+		body     map[string]any
+	}{
+		{
+			endpoint: inner.EndpointSendPatMsg, success: 1, failure: 0,
+			call: func(ctx context.Context) error { return wx.SendPatMsg(ctx, "123@chatroom", "wxid_a") },
+			body: map[string]any{"receiver": "123@chatroom", "wxid": "wxid_a"},
+		},
+		{
+			endpoint: inner.EndpointSendCustomEmotion, success: 1, failure: 0,
+			call: func(ctx context.Context) error { return wx.SendCustomEmotion(ctx, "wxid_a", `C:\e.gif`) },
+			body: map[string]any{"wxid": "wxid_a", "filePath": `C:\e.gif`},
+		},
+		{
+			endpoint: inner.EndpointTopMsg, success: 1, failure: 0,
+			call: func(ctx context.Context) error { return wx.TopMsg(ctx, 123) },
+			body: map[string]any{"msgId": float64(123)},
+		},
+		{
+			endpoint: inner.EndpointRemoveTopMsg, success: 1, failure: 0,
+			call: func(ctx context.Context) error { return wx.RemoveTopMsg(ctx, 123) },
+			body: map[string]any{"msgId": float64(123)},
+		},
+		{
+			endpoint: inner.EndpointDownloadAttach, success: 1, failure: 0,
+			call: func(ctx context.Context) error { return wx.DownloadAttach(ctx, 123) },
+			body: map[string]any{"msgId": float64(123)},
+		},
+		{
+			endpoint: inner.EndpointDecodeImage, success: 1, failure: 0,
+			call: func(ctx context.Context) error { return wx.DecodeImage(ctx, `C:\a.dat`, `C:\out`) },
+			body: map[string]any{"filePath": `C:\a.dat`, "storeDir": `C:\out`},
+		},
+		{
+			endpoint: inner.EndpointGetVoiceByMsgId, success: 1, failure: 0,
+			call: func(ctx context.Context) error { return wx.GetVoiceByMsgId(ctx, 123, `C:\voice`) },
+			body: map[string]any{"msgId": float64(123), "storeDir": `C:\voice`},
+		},
+		{
+			endpoint: inner.EndpointOCR, success: 0, failure: -1,
+			call: func(ctx context.Context) error {
+				text, err := wx.OCR(ctx, `C:\a.png`)
+				if err == nil && text != "识别结果" {
+					return fmt.Errorf("unexpected ocr result %q", text)
+				}
+				return err
+			},
+			body: map[string]any{"imagePath": `C:\a.png`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			ctx := context.Background()
+			fake.code = tt.success
+			assert.Nil(t, tt.call(ctx))
+			assert.Equal(t, tt.endpoint, fake.path)
+			assert.Equal(t, tt.body, fake.body)
+
+			fake.code = tt.failure
+			err := tt.call(ctx)
+			var apiErr *APIError
+			if assert.True(t, errors.As(err, &apiErr)) {
+				assert.Equal(t, tt.endpoint, apiErr.Endpoint)
+				assert.Equal(t, tt.failure, apiErr.Code)
+			}
+			assert.True(t, errors.Is(err, ErrUnexpectedCode))
+		})
+	}
+}
+
+func TestClient_SendPatAndEmotion(t *testing.T) {
+	fake := &endpointServer{code: 1}
+	client := newTestClient(t, fake)
+	ctx := context.Background()
+
+	assert.Nil(t, client.SendPat(ctx, "123@chatroom", "wxid_a"))
+	assert.Equal(t, inner.EndpointSendPatMsg, fake.path)
+	assert.Nil(t, client.SendEmotion(ctx, "wxid_a", `C:\e.gif`))
+	assert.Equal(t, inner.EndpointSendCustomEmotion, fake.path)
+
+	// 参数校验不发起请求
+	fake.path = ""
+	assert.Equal(t, ErrEmptyContent, client.SendPat(ctx, "123@chatroom", ""))
+	assert.Equal(t, ErrEmptyContent, client.SendEmotion(ctx, "wxid_a", ""))
+	assert.Equal(t, ErrEmptyReceiver, client.SendPat(ctx, " ", "wxid_a"))
+	assert.Empty(t, fake.path)
+
+	// 失败时包装为 SendError
+	fake.code = 0
+	err := client.SendPat(ctx, "123@chatroom", "wxid_a")
+	var sendErr *SendError
+	if assert.True(t, errors.As(err, &sendErr)) {
+		assert.Equal(t, "pat", sendErr.Op)
+		assert.Equal(t, "123@chatroom", sendErr.To)
+	}
+	assert.True(t, errors.Is(err, ErrSendFailed))
+	assert.True(t, errors.Is(err, ErrUnexpectedCode))
+
+	err = client.SendEmotion(ctx, "wxid_a", `C:\e.gif`)
+	if assert.True(t, errors.As(err, &sendErr)) {
+		assert.Equal(t, "emotion", sendErr.Op)
+		assert.Equal(t, "wxid_a", sendErr.To)
+	}
+	assert.True(t, errors.Is(err, ErrSendFailed))
+}
@@ -0,0 +1,118 @@
+// Package wxhelper_sdk
+// @Author Clover
+// @Data 2025/1/16 上午9:50:00
+// @Desc tcp 消息分帧
+package wxhelper_sdk
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Framing 同一连接上多条消息的分帧方式
+type Framing int
+
+const (
+	// FramingJSON 连续的 JSON 对象（换行分隔或直接拼接），兼容 wxhelper 每个连接只发送一条消息的行为
+	// ack 与 wxhelper 约定的一致（如 "200 OK"），不带换行
+	FramingJSON Framing = iota
+	// FramingLengthPrefixed 每条消息前带 4 字节大端序长度，ack 以换行结尾（如 "200 OK\n"）
+	FramingLengthPrefixed
+)
+
+const (
+	DefaultReadTimeout    = 60 * time.Second
+	DefaultMaxPayloadSize = defaultMaxBodySize
+
+	ackOK            = "200 OK"
+	ackBadRequest    = "400 Bad Request"
+	ackInternalError = "500 Internal Server Error"
+)
+
+var (
+	ErrPayloadTooLarge = errors.New("message payload too large")
+)
+
+type framer interface {
+	next(msg *Message) error
+}
+
+func newFramer(framing Framing, r io.Reader, maxSize int64) framer {
+	if framing == FramingLengthPrefixed {
+		return &lengthPrefixedFramer{r: r, maxSize: maxSize}
+	}
+	limiter := &limitReader{r: r, max: maxSize}
+	return &jsonFramer{decoder: json.NewDecoder(limiter), limiter: limiter}
+}
+
+// limitReader 限制单条消息读取的字节数，每条消息解析前重置
+type limitReader struct {
+	r    io.Reader
+	max  int64
+	read int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.max > 0 {
+		if l.read >= l.max {
+			return 0, ErrPayloadTooLarge
+		}
+		if remain := l.max - l.read; int64(len(p)) > remain {
+			p = p[:remain]
+		}
+	}
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	return n, err
+}
+
+type jsonFramer struct {
+	decoder *json.Decoder
+	limiter *limitReader
+}
+
+func (f *jsonFramer) next(msg *Message) error {
+	// decoder 可能已预读了下一条消息的部分内容，计数以已缓冲的字节为起点
+	f.limiter.read = 0
+	if buffered, ok := f.decoder.Buffered().(interface{ Len() int }); ok {
+		f.limiter.read = int64(buffered.Len())
+	}
+	if err := f.decoder.Decode(msg); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("truncated message: %w", err)
+		}
+		return err
+	}
+	return nil
+}
+
+type lengthPrefixedFramer struct {
+	r       io.Reader
+	maxSize int64
+}
+
+func (f *lengthPrefixedFramer) next(msg *Message) error {
+	var header [4]byte
+	if _, err := io.ReadFull(f.r, header[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("truncated length header: %w", err)
+		}
+		return err
+	}
+	size := int64(binary.BigEndian.Uint32(header[:]))
+	if f.maxSize > 0 && size > f.maxSize {
+		return fmt.Errorf("%w: %d > %d", ErrPayloadTooLarge, size, f.maxSize)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(f.r, payload); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("truncated message: %w", io.ErrUnexpectedEOF)
+		}
+		return err
+	}
+	return json.Unmarshal(payload, msg)
+}
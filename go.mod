@@ -3,9 +3,11 @@ module wxhelper-sdk
 go 1.22
 
 require (
+	github.com/BurntSushi/toml v1.4.0
 	github.com/eatmoreapple/env v0.0.0-20230613094802-da1bd2d529d4
 	github.com/rs/zerolog v1.33.0
 	github.com/stretchr/testify v1.10.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -14,5 +16,4 @@ require (
 	github.com/mattn/go-isatty v0.0.19 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/sys v0.12.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
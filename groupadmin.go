@@ -0,0 +1,330 @@
+// Package wxhelper_sdk
+// @Author Clover
+// @Data 2025/1/22 上午10:20:00
+// @Desc 群管理：加人、邀请、踢人、群昵称、退群、置顶，批量操作与审计回调
+package wxhelper_sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const memberBatchSize = 20 // 单次请求的成员数
+
+var (
+	ErrNotChatRoomAdmin = errors.New("bot is not admin of the chatroom")
+	ErrNotMember        = errors.New("not a member of the chatroom")
+	ErrAlreadyMember    = errors.New("already a member of the chatroom")
+	ErrCannotRemove     = errors.New("cannot remove owner or self")
+	ErrEmptyMembers     = errors.New("members is empty")
+	ErrPartialFailure   = errors.New("group operation partially failed")
+)
+
+// 群管理操作
+const (
+	GroupOpAdd      = "add"
+	GroupOpInvite   = "invite"
+	GroupOpRemove   = "remove"
+	GroupOpNickname = "nickname"
+	GroupOpQuit     = "quit"
+	GroupOpTop      = "top"
+	GroupOpUntop    = "untop"
+)
+
+// BatchResult 批量成员操作的结果
+type BatchResult struct {
+	Op         string
+	ChatRoomID string
+	Succeeded  []string
+	Failed     map[string]error // 失败的成员及原因（包括校验未通过的成员）
+}
+
+// Err 存在失败的成员时返回 *BatchError
+func (r *BatchResult) Err() error {
+	if len(r.Failed) == 0 {
+		return nil
+	}
+	return &BatchError{Op: r.Op, ChatRoomID: r.ChatRoomID, Failed: r.Failed, Total: len(r.Succeeded) + len(r.Failed)}
+}
+
+// BatchError 批量操作部分或全部失败，可通过 errors.As 获取失败的成员
+type BatchError struct {
+	Op         string
+	ChatRoomID string
+	Failed     map[string]error
+	Total      int
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("%s %d of %d members in %s failed", e.Op, len(e.Failed), e.Total, e.ChatRoomID)
+}
+
+func (e *BatchError) Unwrap() []error {
+	wxids := make([]string, 0, len(e.Failed))
+	for wxid := range e.Failed {
+		wxids = append(wxids, wxid)
+	}
+	sort.Strings(wxids)
+	errs := []error{ErrPartialFailure}
+	for _, wxid := range wxids {
+		errs = append(errs, e.Failed[wxid])
+	}
+	return errs
+}
+
+// AuditEvent 一次群管理操作的记录
+type AuditEvent struct {
+	Op         string
+	ChatRoomID string
+	Operator   string   // 执行操作的账号 wxid
+	Members    []string // 涉及的成员，昵称、置顶等操作为空
+	Detail     string   // 群昵称、消息 id 等
+	Time       time.Time
+	Err        error // 失败原因，批量操作部分失败时为 *BatchError
+}
+
+// auditHooks 审计回调
+type auditHooks struct {
+	mu  sync.RWMutex
+	fns []func(event AuditEvent)
+}
+
+// OnAudit 注册群管理操作的审计回调，每次操作（包括校验失败）结束后同步调用，回调中不应阻塞
+func (c *Client) OnAudit(fn func(event AuditEvent)) {
+	c.audit.mu.Lock()
+	defer c.audit.mu.Unlock()
+	c.audit.fns = append(c.audit.fns, fn)
+}
+
+func (c *Client) fireAudit(event AuditEvent) {
+	event.Time = time.Now()
+	if self := c.Self(); self != nil {
+		event.Operator = self.Wxid
+	}
+	if event.Err != nil {
+		c.log.WarnWithErr(event.Err, "group operation failed", map[string]interface{}{"op": event.Op, "chatRoomId": event.ChatRoomID})
+	} else {
+		c.log.Info("group operation", map[string]interface{}{"op": event.Op, "chatRoomId": event.ChatRoomID, "members": event.Members})
+	}
+	c.audit.mu.RLock()
+	fns := c.audit.fns
+	c.audit.mu.RUnlock()
+	for _, fn := range fns {
+		fn(event)
+	}
+}
+
+// checkChatRoom 群管理操作前的通用校验
+func (c *Client) checkChatRoom(chatRoomID string) error {
+	if c.State() == StateClosed {
+		return ErrClientClosed
+	}
+	if c.State() != StateLoggedIn {
+		return ErrNotLogin
+	}
+	if !isChatRoomID(chatRoomID) {
+		return fmt.Errorf("%w: %q", ErrNotChatRoom, chatRoomID)
+	}
+	return nil
+}
+
+// requireAdmin 获取群信息并校验当前账号为群主或管理员
+func (c *Client) requireAdmin(ctx context.Context, chatRoomID string) (*ChatRoom, error) {
+	room, err := c.ChatRoom(ctx, chatRoomID)
+	if err != nil {
+		return nil, err
+	}
+	if self := c.Self(); self == nil || !room.IsAdmin(self.Wxid) {
+		return nil, ErrNotChatRoomAdmin
+	}
+	return room, nil
+}
+
+// normalizeMembers 去除空白与重复的 wxid
+func normalizeMembers(wxids []string) []string {
+	var members []string
+	for _, wxid := range wxids {
+		if wxid = strings.TrimSpace(wxid); wxid != "" && !slices.Contains(members, wxid) {
+			members = append(members, wxid)
+		}
+	}
+	return members
+}
+
+// batch 校验后分批执行 fn，check 返回非空错误的成员不发起请求，直接记为失败
+// 某一批失败时不重复发起请求（请求可能已部分生效），而是重新获取群信息，由 applied 按实际成员判断每个成员是否成功；
+// applied 为空或群信息获取失败时无法确定结果，整批记为失败
+func (c *Client) batch(ctx context.Context, op, chatRoomID string, wxids []string, check func(wxid string) error,
+	applied func(room *ChatRoom, wxid string) bool, fn func(ctx context.Context, chatRoomID string, wxids []string) error) (*BatchResult, error) {
+	result := &BatchResult{Op: op, ChatRoomID: chatRoomID, Failed: make(map[string]error)}
+	var pending []string
+	for _, wxid := range wxids {
+		if err := check(wxid); err != nil {
+			result.Failed[wxid] = err
+			continue
+		}
+		pending = append(pending, wxid)
+	}
+	for len(pending) > 0 {
+		chunk := pending[:min(memberBatchSize, len(pending))]
+		pending = pending[len(chunk):]
+		if ctx.Err() != nil {
+			c.collect(result, chunk, ctx.Err())
+			continue
+		}
+		err := fn(ctx, chatRoomID, chunk)
+		if err == nil || applied == nil {
+			c.collect(result, chunk, err)
+			continue
+		}
+		room, refreshErr := c.RefreshChatRoom(ctx, chatRoomID)
+		if refreshErr != nil {
+			c.log.WarnWithErr(refreshErr, "verify members after failed batch error", map[string]interface{}{"chatRoomId": chatRoomID, "op": op})
+			c.collect(result, chunk, err)
+			continue
+		}
+		for _, wxid := range chunk {
+			if applied(room, wxid) {
+				c.collect(result, []string{wxid}, nil)
+			} else {
+				c.collect(result, []string{wxid}, err)
+			}
+		}
+	}
+	if len(result.Succeeded) > 0 {
+		c.InvalidateChatRoom(chatRoomID)
+	}
+	err := result.Err()
+	c.fireAudit(AuditEvent{Op: op, ChatRoomID: chatRoomID, Members: wxids, Err: err})
+	return result, err
+}
+
+func (c *Client) collect(result *BatchResult, wxids []string, err error) {
+	for _, wxid := range wxids {
+		if err != nil {
+			result.Failed[wxid] = err
+		} else {
+			result.Succeeded = append(result.Succeeded, wxid)
+		}
+	}
+}
+
+// memberOp 批量成员操作的通用流程，校验失败时返回 nil 结果
+func (c *Client) memberOp(ctx context.Context, op, chatRoomID string, wxids []string, needAdmin bool,
+	check func(room *ChatRoom, wxid string) error, applied func(room *ChatRoom, wxid string) bool,
+	fn func(ctx context.Context, chatRoomID string, wxids []string) error) (*BatchResult, error) {
+	wxids = normalizeMembers(wxids)
+	err := c.checkChatRoom(chatRoomID)
+	if err == nil && len(wxids) == 0 {
+		err = ErrEmptyMembers
+	}
+	var room *ChatRoom
+	if err == nil {
+		if needAdmin {
+			room, err = c.requireAdmin(ctx, chatRoomID)
+		} else {
+			room, err = c.ChatRoom(ctx, chatRoomID)
+		}
+	}
+	if err != nil {
+		c.fireAudit(AuditEvent{Op: op, ChatRoomID: chatRoomID, Members: wxids, Err: err})
+		return nil, err
+	}
+	return c.batch(ctx, op, chatRoomID, wxids, func(wxid string) error { return check(room, wxid) }, applied, fn)
+}
+
+// AddMembers 直接拉好友进群（通常适用于 40 人以下的群），已在群内的成员记为 ErrAlreadyMember
+// 部分成员失败时返回结果及 *BatchError
+func (c *Client) AddMembers(ctx context.Context, chatRoomID string, wxids []string) (*BatchResult, error) {
+	return c.memberOp(ctx, GroupOpAdd, chatRoomID, wxids, false, notMember, (*ChatRoom).HasMember, c.wxClient.AddMemberIntoChatRoom)
+}
+
+// InviteMembers 发送入群邀请（适用于 40 人以上的群），已在群内的成员记为 ErrAlreadyMember
+// 被邀请人接受前不会出现在成员列表中，某一批请求失败时整批记为失败
+func (c *Client) InviteMembers(ctx context.Context, chatRoomID string, wxids []string) (*BatchResult, error) {
+	return c.memberOp(ctx, GroupOpInvite, chatRoomID, wxids, false, notMember, nil, c.wxClient.InviteMemberToChatRoom)
+}
+
+// RemoveMembers 将成员移出群聊，当前账号需为群主或管理员，不能移除群主与自己
+func (c *Client) RemoveMembers(ctx context.Context, chatRoomID string, wxids []string) (*BatchResult, error) {
+	self := c.Self()
+	check := func(room *ChatRoom, wxid string) error {
+		switch {
+		case wxid == room.Owner || self != nil && wxid == self.Wxid:
+			return ErrCannotRemove
+		case !room.HasMember(wxid):
+			return ErrNotMember
+		}
+		return nil
+	}
+	removed := func(room *ChatRoom, wxid string) bool { return !room.HasMember(wxid) }
+	return c.memberOp(ctx, GroupOpRemove, chatRoomID, wxids, true, check, removed, c.wxClient.DelMemberFromChatRoom)
+}
+
+func notMember(room *ChatRoom, wxid string) error {
+	if room.HasMember(wxid) {
+		return ErrAlreadyMember
+	}
+	return nil
+}
+
+// SetGroupNickname 修改自己在群内的昵称
+func (c *Client) SetGroupNickname(ctx context.Context, chatRoomID string, nickname string) error {
+	err := c.checkChatRoom(chatRoomID)
+	if err == nil && strings.TrimSpace(nickname) == "" {
+		err = ErrEmptyContent
+	}
+	self := c.Self()
+	if err == nil && self == nil {
+		err = ErrNotLogin
+	}
+	if err == nil {
+		err = c.wxClient.ModifyNickname(ctx, chatRoomID, self.Wxid, nickname)
+	}
+	if err == nil {
+		c.InvalidateChatRoom(chatRoomID)
+	}
+	c.fireAudit(AuditEvent{Op: GroupOpNickname, ChatRoomID: chatRoomID, Detail: nickname, Err: err})
+	return err
+}
+
+// QuitChatRoom 退出群聊
+func (c *Client) QuitChatRoom(ctx context.Context, chatRoomID string) error {
+	err := c.checkChatRoom(chatRoomID)
+	if err == nil {
+		err = c.wxClient.QuitChatRoom(ctx, chatRoomID)
+	}
+	if err == nil {
+		c.InvalidateChatRoom(chatRoomID)
+	}
+	c.fireAudit(AuditEvent{Op: GroupOpQuit, ChatRoomID: chatRoomID, Err: err})
+	return err
+}
+
+// TopMessage 置顶群消息，当前账号需为群主或管理员
+func (c *Client) TopMessage(ctx context.Context, chatRoomID string, msgID int64) error {
+	return c.topOp(ctx, GroupOpTop, chatRoomID, msgID, c.wxClient.TopMsg)
+}
+
+// UntopMessage 取消置顶群消息，当前账号需为群主或管理员
+func (c *Client) UntopMessage(ctx context.Context, chatRoomID string, msgID int64) error {
+	return c.topOp(ctx, GroupOpUntop, chatRoomID, msgID, c.wxClient.RemoveTopMsg)
+}
+
+func (c *Client) topOp(ctx context.Context, op, chatRoomID string, msgID int64, fn func(ctx context.Context, msgID int64) error) error {
+	err := c.checkChatRoom(chatRoomID)
+	if err == nil {
+		_, err = c.requireAdmin(ctx, chatRoomID)
+	}
+	if err == nil {
+		err = fn(ctx, msgID)
+	}
+	c.fireAudit(AuditEvent{Op: op, ChatRoomID: chatRoomID, Detail: fmt.Sprint(msgID), Err: err})
+	return err
+}
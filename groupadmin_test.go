@@ -0,0 +1,152 @@
+package wxhelper_sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeGroupAdmin 模拟群管理接口，成员包含 wxid_bad* 的请求返回失败，但其余成员的变动仍会生效
+type fakeGroupAdmin struct {
+	mu      sync.Mutex
+	owner   string
+	members []string
+	calls   map[string][]string // 接口 -> 每次请求的 memberIds
+	fetches int
+}
+
+func (f *fakeGroupAdmin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var body map[string]any
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	switch r.URL.Path {
+	case "/api/getMemberFromChatRoom":
+		f.fetches++
+		_, _ = fmt.Fprintf(w, `{"code":1,"data":{"chatRoomId":"123@chatroom","admin":%q,"members":%q,"memberNickname":%q}}`,
+			f.owner, strings.Join(f.members, memberSeparator), strings.Repeat(memberSeparator+"n", len(f.members)))
+	case "/api/getChatRoomDetailInfo":
+		_, _ = w.Write([]byte(`{"code":1,"data":{"chatRoomId":"123@chatroom"}}`))
+	default:
+		members, _ := body["memberIds"].(string)
+		f.calls[r.URL.Path] = append(f.calls[r.URL.Path], members)
+		for _, wxid := range strings.Split(members, ",") {
+			switch {
+			case strings.HasPrefix(wxid, "wxid_bad"):
+			case r.URL.Path == "/api/addMemberToChatRoom":
+				f.members = append(f.members, wxid)
+			case r.URL.Path == "/api/delMemberFromChatRoom":
+				f.members = slices.DeleteFunc(f.members, func(m string) bool { return m == wxid })
+			}
+		}
+		code := 1
+		if strings.Contains(members, "wxid_bad") {
+			code = 0
+		}
+		_, _ = fmt.Fprintf(w, `{"code":%d,"msg":"success"}`, code)
+	}
+}
+
+func newFakeGroupAdmin(owner string) *fakeGroupAdmin {
+	return &fakeGroupAdmin{owner: owner, members: []string{"wxid_owner", "wxid_bot", "wxid_a", "wxid_bad"}, calls: make(map[string][]string)}
+}
+
+// newGroupAdminClient 以 wxid_bot 登录的客户端，返回记录的审计事件
+func newGroupAdminClient(t *testing.T, fake *fakeGroupAdmin) (*Client, *[]AuditEvent) {
+	client := newTestClient(t, fake, WithRetryPolicy(RetryPolicy{}))
+	client.account.Store(&Account{Wxid: "wxid_bot"})
+	var events []AuditEvent
+	client.OnAudit(func(event AuditEvent) { events = append(events, event) })
+	return client, &events
+}
+
+func TestClient_RemoveMembers(t *testing.T) {
+	fake := newFakeGroupAdmin("wxid_bot")
+	client, events := newGroupAdminClient(t, fake)
+	ctx := context.Background()
+
+	result, err := client.RemoveMembers(ctx, "123@chatroom", []string{"wxid_a", "wxid_bad", " wxid_a", "wxid_bot", "wxid_x"})
+	var batchErr *BatchError
+	if assert.True(t, errors.As(err, &batchErr)) {
+		assert.Equal(t, GroupOpRemove, batchErr.Op)
+		assert.Equal(t, 4, batchErr.Total)
+	}
+	assert.True(t, errors.Is(err, ErrPartialFailure))
+	assert.True(t, errors.Is(err, ErrUnexpectedCode))
+	assert.Equal(t, []string{"wxid_a"}, result.Succeeded)
+	assert.Len(t, result.Failed, 3)
+	assert.True(t, errors.Is(result.Failed["wxid_bad"], ErrUnexpectedCode))
+	assert.Equal(t, ErrCannotRemove, result.Failed["wxid_bot"])
+	assert.Equal(t, ErrNotMember, result.Failed["wxid_x"])
+	// 整批失败后不重复请求，按重新获取的成员列表判断每个成员的结果
+	assert.Equal(t, []string{"wxid_a,wxid_bad"}, fake.calls["/api/delMemberFromChatRoom"])
+	assert.Equal(t, 2, fake.fetches)
+
+	if assert.Len(t, *events, 1) {
+		event := (*events)[0]
+		assert.Equal(t, GroupOpRemove, event.Op)
+		assert.Equal(t, "wxid_bot", event.Operator)
+		assert.Equal(t, []string{"wxid_a", "wxid_bad", "wxid_bot", "wxid_x"}, event.Members)
+		assert.Equal(t, err, event.Err)
+	}
+
+	// 成员变动后重新获取群信息
+	_, _ = client.ChatRoom(ctx, "123@chatroom")
+	assert.Equal(t, 3, fake.fetches)
+}
+
+func TestClient_GroupAdminValidation(t *testing.T) {
+	fake := newFakeGroupAdmin("wxid_owner")
+	client, events := newGroupAdminClient(t, fake)
+	ctx := context.Background()
+
+	_, err := client.RemoveMembers(ctx, "123@chatroom", []string{"wxid_a"})
+	assert.Equal(t, ErrNotChatRoomAdmin, err)
+	assert.Equal(t, ErrNotChatRoomAdmin, client.TopMessage(ctx, "123@chatroom", 1))
+	assert.Empty(t, fake.calls)
+	_, err = client.AddMembers(ctx, "123@chatroom", []string{" "})
+	assert.Equal(t, ErrEmptyMembers, err)
+	_, err = client.InviteMembers(ctx, "wxid_a", []string{"wxid_b"})
+	assert.True(t, errors.Is(err, ErrNotChatRoom))
+	assert.Len(t, *events, 4)
+	for _, event := range *events {
+		assert.NotNil(t, event.Err)
+	}
+
+	client.state.Store(int32(StateLoggedOut))
+	assert.Equal(t, ErrNotLogin, client.QuitChatRoom(ctx, "123@chatroom"))
+}
+
+func TestClient_AddMembers(t *testing.T) {
+	fake := newFakeGroupAdmin("wxid_owner")
+	client, _ := newGroupAdminClient(t, fake)
+	ctx := context.Background()
+
+	result, err := client.AddMembers(ctx, "123@chatroom", []string{"wxid_a", "wxid_c", "wxid_d"})
+	assert.True(t, errors.Is(err, ErrPartialFailure))
+	assert.Equal(t, []string{"wxid_c", "wxid_d"}, result.Succeeded)
+	assert.Equal(t, ErrAlreadyMember, result.Failed["wxid_a"])
+	assert.Equal(t, []string{"wxid_c,wxid_d"}, fake.calls["/api/addMemberToChatRoom"])
+
+	result, err = client.InviteMembers(ctx, "123@chatroom", []string{"wxid_e"})
+	assert.Nil(t, err)
+	assert.Nil(t, result.Err())
+	assert.Equal(t, []string{"wxid_e"}, result.Succeeded)
+
+	// 邀请无法从成员列表确认结果，失败时整批记为失败
+	result, err = client.AddMembers(ctx, "123@chatroom", []string{"wxid_f", "wxid_bad2"})
+	assert.True(t, errors.Is(err, ErrPartialFailure))
+	assert.Equal(t, []string{"wxid_f"}, result.Succeeded)
+	result, err = client.InviteMembers(ctx, "123@chatroom", []string{"wxid_g", "wxid_bad2"})
+	assert.True(t, errors.Is(err, ErrUnexpectedCode))
+	assert.Empty(t, result.Succeeded)
+	assert.Len(t, result.Failed, 2)
+	assert.Equal(t, []string{"wxid_g,wxid_bad2"}, fake.calls["/api/InviteMemberToChatRoom"][1:])
+}
@@ -0,0 +1,329 @@
+// Package wxhelper_sdk
+// @Author Clover
+// @Data 2025/1/22 下午4:30:00
+// @Desc 群成员变动事件：入群、移出、改群名、群公告
+package wxhelper_sdk
+
+import (
+	"encoding/xml"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// EventMember 事件中涉及的成员，仅能从系统消息文本中得到昵称时 Wxid 可能为空
+type EventMember struct {
+	Wxid     string `json:"wxid"`
+	Nickname string `json:"nickname"`
+}
+
+// GroupEvent 群事件，可通过类型断言得到 *MemberJoined、*MemberRemoved、*RoomRenamed、*NoticeChanged
+type GroupEvent interface {
+	ChatRoomID() string
+	Message() *Message // 产生事件的系统消息
+}
+
+type groupEvent struct {
+	chatRoomID string
+	message    *Message
+}
+
+func (e groupEvent) ChatRoomID() string {
+	return e.chatRoomID
+}
+
+func (e groupEvent) Message() *Message {
+	return e.message
+}
+
+// MemberJoined 成员入群：被邀请、扫码或自己（当前账号）被拉入群聊
+type MemberJoined struct {
+	groupEvent
+	Inviter EventMember   // 邀请人或二维码分享者，无邀请人时为空
+	Members []EventMember // 入群的成员
+	QRCode  bool          // 通过扫描二维码入群
+}
+
+// MemberRemoved 成员被移出群聊，Members 包含当前账号时表示自己被移出
+type MemberRemoved struct {
+	groupEvent
+	Operator EventMember
+	Members  []EventMember
+}
+
+// RoomRenamed 群名被修改
+type RoomRenamed struct {
+	groupEvent
+	Operator EventMember
+	Name     string
+}
+
+// NoticeChanged 群公告被修改，Notice 为空表示仅知道公告已修改
+type NoticeChanged struct {
+	groupEvent
+	Operator EventMember
+	Notice   string
+}
+
+// 群系统消息的文本，"你" 表示当前账号
+var (
+	reInvite  = regexp.MustCompile(`^(?:"(.+?)"|你)邀请(?:"(.+)"|你)加入了群聊`)
+	reQRCode  = regexp.MustCompile(`^"(.+?)"通过扫描(?:"(.+?)"|你)分享的二维码加入群聊`)
+	reJoined  = regexp.MustCompile(`^"(.+)"加入了群聊$`)
+	reKick    = regexp.MustCompile(`^你将"(.+)"移出了群聊`)
+	reKicked  = regexp.MustCompile(`^你被"(.+?)"移出群聊`)
+	reRenamed = regexp.MustCompile(`^(?:"(.+?)"|你)修改群名为“(.*)”$`)
+	reNotice  = regexp.MustCompile(`^(?:"(.+?)"|你)(?:修改|发布)了?群公告`)
+)
+
+const nameSeparator = "、"
+
+// sysMsg 10000、10002 消息中的 <sysmsg>
+type sysMsg struct {
+	Type     string `xml:"type,attr"`
+	Template struct {
+		Content struct {
+			Template string `xml:"template"`
+			Links    []struct {
+				Name    string `xml:"name,attr"`
+				Members []struct {
+					Username string `xml:"username"`
+					Nickname string `xml:"nickname"`
+				} `xml:"memberlist>member"`
+			} `xml:"link_list>link"`
+		} `xml:"content_template"`
+	} `xml:"sysmsgtemplate"`
+	Announcement struct {
+		Content string `xml:"content"`
+	} `xml:"mmchatroombarannouncememt"`
+}
+
+// eventParser 解析群系统消息，names 为昵称到 wxid 的映射（来自 sysmsgtemplate 或群成员缓存）
+type eventParser struct {
+	msg    *Message
+	self   EventMember
+	names  map[string]string
+	lookup func(name string) string // 按群昵称查找 wxid，可为空
+}
+
+func (p *eventParser) member(name string) EventMember {
+	wxid := p.names[name]
+	if wxid == "" && p.lookup != nil {
+		wxid = p.lookup(name)
+	}
+	return EventMember{Wxid: wxid, Nickname: name}
+}
+
+// operator 文本中的操作人，name 为空表示当前账号
+func (p *eventParser) operator(name string) EventMember {
+	if name == "" {
+		return p.self
+	}
+	return p.member(name)
+}
+
+func (p *eventParser) members(names string) []EventMember {
+	var members []EventMember
+	for _, name := range strings.Split(names, nameSeparator) {
+		if name != "" {
+			members = append(members, p.member(name))
+		}
+	}
+	return members
+}
+
+// parseText 解析系统消息文本
+func (p *eventParser) parseText(text string) GroupEvent {
+	base := groupEvent{chatRoomID: p.msg.ChatRoomID(), message: p.msg}
+	if m := reInvite.FindStringSubmatch(text); m != nil {
+		event := &MemberJoined{groupEvent: base, Inviter: p.operator(m[1]), Members: p.members(m[2])}
+		if m[2] == "" { // 自己被邀请
+			event.Members = []EventMember{p.self}
+		}
+		return event
+	}
+	if m := reQRCode.FindStringSubmatch(text); m != nil {
+		return &MemberJoined{groupEvent: base, Inviter: p.operator(m[2]), Members: p.members(m[1]), QRCode: true}
+	}
+	if m := reJoined.FindStringSubmatch(text); m != nil {
+		return &MemberJoined{groupEvent: base, Members: p.members(m[1])}
+	}
+	if m := reKick.FindStringSubmatch(text); m != nil {
+		return &MemberRemoved{groupEvent: base, Operator: p.self, Members: p.members(m[1])}
+	}
+	if m := reKicked.FindStringSubmatch(text); m != nil {
+		return &MemberRemoved{groupEvent: base, Operator: p.member(m[1]), Members: []EventMember{p.self}}
+	}
+	if m := reRenamed.FindStringSubmatch(text); m != nil {
+		return &RoomRenamed{groupEvent: base, Operator: p.operator(m[1]), Name: m[2]}
+	}
+	if m := reNotice.FindStringSubmatch(text); m != nil {
+		return &NoticeChanged{groupEvent: base, Operator: p.operator(m[1])}
+	}
+	return nil
+}
+
+// parseSysMsg 解析 <sysmsg>：sysmsgtemplate 按模板还原文本，同时得到昵称对应的 wxid
+func (p *eventParser) parseSysMsg(data string) GroupEvent {
+	var msg sysMsg
+	if err := xml.Unmarshal([]byte(data), &msg); err != nil {
+		return nil
+	}
+	switch msg.Type {
+	case "mmchatroombarannouncememt":
+		notice := strings.TrimSpace(msg.Announcement.Content)
+		return &NoticeChanged{groupEvent: groupEvent{chatRoomID: p.msg.ChatRoomID(), message: p.msg}, Notice: notice}
+	case "sysmsgtemplate":
+		text := msg.Template.Content.Template
+		for _, link := range msg.Template.Content.Links {
+			names := make([]string, 0, len(link.Members))
+			for _, m := range link.Members {
+				names = append(names, m.Nickname)
+				p.names[m.Nickname] = m.Username
+			}
+			text = strings.ReplaceAll(text, "$"+link.Name+"$", strings.Join(names, nameSeparator))
+		}
+		return p.parseText(text)
+	}
+	return nil
+}
+
+// parseGroupEvent 将群内的系统消息解析为群事件，不是群事件时返回 nil
+func parseGroupEvent(m *Message, lookup func(name string) string) GroupEvent {
+	if m.ChatRoomID() == "" || (m.Type != MsgTypeSys && m.Type != MsgTypeRecalled) {
+		return nil
+	}
+	p := &eventParser{msg: m, names: make(map[string]string), lookup: lookup}
+	if m.account != nil {
+		p.self = EventMember{Wxid: m.account.Wxid, Nickname: m.account.Name}
+	}
+	text := strings.TrimSpace(m.Text())
+	if strings.HasPrefix(text, "<sysmsg") {
+		return p.parseSysMsg(text)
+	}
+	if m.Type != MsgTypeSys {
+		return nil
+	}
+	return p.parseText(text)
+}
+
+// GroupEvent 将群系统消息解析为群事件，不是群事件时返回 false
+// 系统消息文本中只有昵称，客户端缓存了群成员时会按群昵称补全 wxid
+func (m *Message) GroupEvent() (GroupEvent, bool) {
+	var lookup func(string) string
+	if m.client != nil {
+		lookup = m.client.chatRooms.lookup(m.ChatRoomID())
+	}
+	event := parseGroupEvent(m, lookup)
+	return event, event != nil
+}
+
+// MatchGroupEvent 可解析为群事件的系统消息
+func MatchGroupEvent() Predicate {
+	return func(message *Message) bool {
+		_, ok := message.GroupEvent()
+		return ok
+	}
+}
+
+// groupEventHooks 群事件订阅
+type groupEventHooks struct {
+	mu  sync.RWMutex
+	fns []func(event GroupEvent)
+}
+
+// OnGroupEvent 订阅群事件，收到消息时（放入消息缓冲区之前）同步调用，回调中不应阻塞
+func (c *Client) OnGroupEvent(fn func(event GroupEvent)) {
+	c.groupEvents.mu.Lock()
+	defer c.groupEvents.mu.Unlock()
+	c.groupEvents.fns = append(c.groupEvents.fns, fn)
+}
+
+// handleGroupEvent 解析群事件、更新群信息缓存并通知订阅者
+func (c *Client) handleGroupEvent(m *Message) {
+	event, ok := m.GroupEvent()
+	if !ok {
+		c.chatRooms.observe(m) // 无法识别的群系统消息仍使缓存失效
+		return
+	}
+	c.chatRooms.apply(event)
+	c.log.Debug("group event", map[string]interface{}{"chatRoomId": event.ChatRoomID(), "msgId": m.MsgId})
+	c.groupEvents.mu.RLock()
+	fns := c.groupEvents.fns
+	c.groupEvents.mu.RUnlock()
+	for _, fn := range fns {
+		fn(event)
+	}
+}
+
+// lookup 按群成员缓存将群昵称解析为 wxid，群未缓存时返回 nil，多个成员同名时返回空
+func (c *chatRoomCache) lookup(chatRoomID string) func(name string) string {
+	c.mu.Lock()
+	room, ok := c.rooms[chatRoomID]
+	c.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return func(name string) string {
+		var wxid string
+		for _, m := range room.Members {
+			if m.DisplayName != name {
+				continue
+			}
+			if wxid != "" { // 多个成员同名时无法确定
+				return ""
+			}
+			wxid = m.Wxid
+		}
+		return wxid
+	}
+}
+
+// update 以副本修改缓存的群信息，fn 返回 false 或群未缓存时使缓存失效
+func (c *chatRoomCache) update(chatRoomID string, fn func(room *ChatRoom) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	room, ok := c.rooms[chatRoomID]
+	if !ok {
+		return
+	}
+	clone := room.clone()
+	if !fn(clone) {
+		delete(c.rooms, chatRoomID)
+		return
+	}
+	c.rooms[chatRoomID] = clone
+}
+
+// apply 按群事件更新缓存，成员 wxid 未知时使缓存失效
+func (c *chatRoomCache) apply(event GroupEvent) {
+	c.update(event.ChatRoomID(), func(room *ChatRoom) bool {
+		switch e := event.(type) {
+		case *MemberJoined:
+			for _, m := range e.Members {
+				if m.Wxid == "" {
+					return false
+				}
+				if !room.HasMember(m.Wxid) {
+					room.Members = append(room.Members, Member{Wxid: m.Wxid, DisplayName: m.Nickname})
+				}
+			}
+		case *MemberRemoved:
+			for _, m := range e.Members {
+				if m.Wxid == "" {
+					return false
+				}
+				room.Members = slices.DeleteFunc(room.Members, func(member Member) bool { return member.Wxid == m.Wxid })
+			}
+		case *RoomRenamed:
+			room.Name = e.Name
+		case *NoticeChanged:
+			if e.Notice == "" {
+				return false
+			}
+			room.Notice = e.Notice
+		}
+		return true
+	})
+}
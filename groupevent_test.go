@@ -0,0 +1,144 @@
+package wxhelper_sdk
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func sysMessage(content string) *Message {
+	return &Message{FromUser: "123@chatroom", ToUser: "wxid_bot", Type: MsgTypeSys, Content: content,
+		account: &Account{Wxid: "wxid_bot", Name: "bot"}}
+}
+
+func TestMessage_GroupEvent(t *testing.T) {
+	self := EventMember{Wxid: "wxid_bot", Nickname: "bot"}
+	tests := []struct {
+		name    string
+		content string
+		want    GroupEvent
+	}{
+		{"invite", `"张三"邀请"李四、王五"加入了群聊`,
+			&MemberJoined{Inviter: EventMember{Nickname: "张三"}, Members: []EventMember{{Nickname: "李四"}, {Nickname: "王五"}}}},
+		{"self invite", `你邀请"李四"加入了群聊`,
+			&MemberJoined{Inviter: self, Members: []EventMember{{Nickname: "李四"}}}},
+		{"invited", `"张三"邀请你加入了群聊，群聊参与人还有：李四`,
+			&MemberJoined{Inviter: EventMember{Nickname: "张三"}, Members: []EventMember{self}}},
+		{"qrcode", `"李四"通过扫描"张三"分享的二维码加入群聊`,
+			&MemberJoined{Inviter: EventMember{Nickname: "张三"}, Members: []EventMember{{Nickname: "李四"}}, QRCode: true}},
+		{"joined", `"李四"加入了群聊`, &MemberJoined{Members: []EventMember{{Nickname: "李四"}}}},
+		{"kick", `你将"李四"移出了群聊`, &MemberRemoved{Operator: self, Members: []EventMember{{Nickname: "李四"}}}},
+		{"kicked", `你被"张三"移出群聊`, &MemberRemoved{Operator: EventMember{Nickname: "张三"}, Members: []EventMember{self}}},
+		{"rename", `"张三"修改群名为“新群名”`, &RoomRenamed{Operator: EventMember{Nickname: "张三"}, Name: "新群名"}},
+		{"notice", `你修改了群公告`, &NoticeChanged{Operator: self}},
+		{"template", `<sysmsg type="sysmsgtemplate"><sysmsgtemplate><content_template type="tmpl_type_profile">
+<template><![CDATA["$username$"邀请"$names$"加入了群聊]]></template><link_list>
+<link name="username" type="link_profile"><memberlist><member><username><![CDATA[wxid_zs]]></username><nickname><![CDATA[张三]]></nickname></member></memberlist></link>
+<link name="names" type="link_profile"><memberlist><member><username><![CDATA[wxid_ls]]></username><nickname><![CDATA[李四]]></nickname></member>
+<member><username><![CDATA[wxid_ww]]></username><nickname><![CDATA[王五]]></nickname></member></memberlist><separator><![CDATA[、]]></separator></link>
+</link_list></content_template></sysmsgtemplate></sysmsg>`,
+			&MemberJoined{Inviter: EventMember{Wxid: "wxid_zs", Nickname: "张三"},
+				Members: []EventMember{{Wxid: "wxid_ls", Nickname: "李四"}, {Wxid: "wxid_ww", Nickname: "王五"}}}},
+		{"announcement", `<sysmsg type="mmchatroombarannouncememt"><mmchatroombarannouncememt><content><![CDATA[新公告]]></content></mmchatroombarannouncememt></sysmsg>`,
+			&NoticeChanged{Notice: "新公告"}},
+		{"other", `"张三"拍了拍"李四"`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := sysMessage(tt.content)
+			event, ok := msg.GroupEvent()
+			if tt.want == nil {
+				assert.False(t, ok)
+				return
+			}
+			if !assert.True(t, ok) {
+				return
+			}
+			assert.Equal(t, "123@chatroom", event.ChatRoomID())
+			assert.Same(t, msg, event.Message())
+			switch want := tt.want.(type) {
+			case *MemberJoined:
+				want.groupEvent = groupEvent{chatRoomID: "123@chatroom", message: msg}
+			case *MemberRemoved:
+				want.groupEvent = groupEvent{chatRoomID: "123@chatroom", message: msg}
+			case *RoomRenamed:
+				want.groupEvent = groupEvent{chatRoomID: "123@chatroom", message: msg}
+			case *NoticeChanged:
+				want.groupEvent = groupEvent{chatRoomID: "123@chatroom", message: msg}
+			}
+			assert.Equal(t, tt.want, event)
+		})
+	}
+
+	// 私聊中的系统消息不是群事件
+	private := sysMessage(`"李四"加入了群聊`)
+	private.FromUser = "wxid_a"
+	assert.False(t, MatchGroupEvent()(private))
+}
+
+func TestClient_HandleGroupEvent(t *testing.T) {
+	client := NewClient(WithCacheDir(t.TempDir()))
+	t.Cleanup(client.stop)
+	client.chatRooms.put(&ChatRoom{ID: "123@chatroom", UpdatedAt: time.Now(), Members: []Member{
+		{Wxid: "wxid_zs", DisplayName: "张三", IsAdmin: true}, {Wxid: "wxid_ls", DisplayName: "李四"},
+	}})
+	var events []GroupEvent
+	client.OnGroupEvent(func(event GroupEvent) {
+		client.OnGroupEvent(func(GroupEvent) {}) // 回调中可再订阅
+		events = append(events, event)
+	})
+	receive := func(content string) *ChatRoom {
+		msg := sysMessage(content)
+		msg.client = client
+		client.handleGroupEvent(msg)
+		room, _ := client.chatRooms.get("123@chatroom")
+		return room
+	}
+
+	room := receive(`"张三"将"李四"移出了群聊`) // 无法识别，使缓存失效
+	assert.Nil(t, room)
+	assert.Empty(t, events)
+
+	client.chatRooms.put(&ChatRoom{ID: "123@chatroom", UpdatedAt: time.Now(), Members: []Member{
+		{Wxid: "wxid_zs", DisplayName: "张三", IsAdmin: true}, {Wxid: "wxid_ls", DisplayName: "李四"},
+	}})
+	// 按群成员缓存补全 wxid 后直接更新缓存
+	room = receive(`你将"李四"移出了群聊`)
+	if assert.NotNil(t, room) {
+		assert.Equal(t, []string{"wxid_zs"}, room.Wxids())
+	}
+	if assert.Len(t, events, 1) {
+		removed := events[0].(*MemberRemoved)
+		assert.Equal(t, "wxid_ls", removed.Members[0].Wxid)
+	}
+
+	room = receive(`"张三"修改群名为“新群名”`)
+	assert.Equal(t, "新群名", room.Name)
+	room = receive(`<sysmsg type="mmchatroombarannouncememt"><mmchatroombarannouncememt><content>公告</content></mmchatroombarannouncememt></sysmsg>`)
+	assert.Equal(t, "公告", room.Notice)
+
+	// 新成员的 wxid 未知时使缓存失效
+	room = receive(`"张三"邀请"王五"加入了群聊`)
+	assert.Nil(t, room)
+	assert.Len(t, events, 4)
+}
+
+func TestChatRoomCache_LookupDuplicateName(t *testing.T) {
+	cache := newChatRoomCache(0)
+	assert.Nil(t, cache.lookup("123@chatroom"))
+	cache.put(&ChatRoom{ID: "123@chatroom", UpdatedAt: time.Now(), Members: []Member{
+		{Wxid: "wxid_zs", DisplayName: "张三"}, {Wxid: "wxid_ls1", DisplayName: "李四"}, {Wxid: "wxid_ls2", DisplayName: "李四"},
+	}})
+	lookup := cache.lookup("123@chatroom")
+	assert.Equal(t, "wxid_zs", lookup("张三"))
+	assert.Equal(t, "", lookup("李四")) // 同名成员无法确定 wxid
+	assert.Equal(t, "", lookup("王五"))
+
+	// 同名成员被移出时不猜测 wxid，缓存失效
+	msg := sysMessage(`你将"李四"移出了群聊`)
+	event := parseGroupEvent(msg, lookup).(*MemberRemoved)
+	assert.Equal(t, []EventMember{{Nickname: "李四"}}, event.Members)
+	cache.apply(event)
+	_, ok := cache.get("123@chatroom")
+	assert.False(t, ok)
+}
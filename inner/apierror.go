@@ -0,0 +1,62 @@
+// Package inner
+// @Author Clover
+// @Data 2025/1/18 上午10:00:00
+// @Desc wxhelper 接口错误
+package inner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	ErrAPI              = errors.New("wxhelper api error")      // 所有 APIError 均匹配
+	ErrRequest          = errors.New("request wxhelper failed") // 网络错误、超时等，未得到响应
+	ErrUnexpectedStatus = errors.New("unexpected http status")
+	ErrUnexpectedCode   = errors.New("unexpected response code")
+)
+
+// APIError 请求 wxhelper 接口失败，可通过 errors.Is 判断失败原因
+// （ErrRequest、ErrUnexpectedStatus、ErrJSONDecoder、ErrUnexpectedCode），通过 errors.As 获取返回码
+type APIError struct {
+	Endpoint   string // 接口路径
+	StatusCode int    // http 状态码，未得到响应时为 0
+	Code       int    // result.Code
+	Msg        string // result.Msg
+	Err        error
+}
+
+func (e *APIError) Error() string {
+	switch {
+	case e.StatusCode == 0:
+		return fmt.Sprintf("wxhelper %s: %v", e.Endpoint, e.Err)
+	case errors.Is(e.Err, ErrUnexpectedCode):
+		return fmt.Sprintf("wxhelper %s: unexpected code %d, msg: %s", e.Endpoint, e.Code, e.Msg)
+	}
+	return fmt.Sprintf("wxhelper %s: status %d: %v", e.Endpoint, e.StatusCode, e.Err)
+}
+
+func (e *APIError) Unwrap() []error {
+	return []error{ErrAPI, e.Err}
+}
+
+// decode 解析接口返回，请求错误、非 2xx 状态、解析失败及返回码不是该接口的成功码时返回 *APIError
+func decode[T any](endpoint string, resp *http.Response, err error) (result[T], error) {
+	var r result[T]
+	if err != nil {
+		return r, &APIError{Endpoint: endpoint, Err: fmt.Errorf("%w: %w", ErrRequest, err)}
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return r, &APIError{Endpoint: endpoint, StatusCode: resp.StatusCode, Err: ErrUnexpectedStatus}
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return r, &APIError{Endpoint: endpoint, StatusCode: resp.StatusCode, Err: fmt.Errorf("%w: %w", ErrJSONDecoder, err)}
+	}
+	if !isSuccess(endpoint, r.Code) {
+		return r, &APIError{Endpoint: endpoint, StatusCode: resp.StatusCode, Code: r.Code, Msg: r.Msg, Err: ErrUnexpectedCode}
+	}
+	return r, nil
+}
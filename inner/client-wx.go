@@ -6,9 +6,9 @@ package inner
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -22,75 +22,79 @@ type WxClient struct {
 }
 
 var (
-	ErrWxClientResp = errors.New("wx-client's response err")
-	ErrJSONDecoder  = errors.New("JSON decoder error")
-	colonSeparator  = ":"
+	// Deprecated: 使用 ErrRequest
+	ErrWxClientResp   = ErrRequest
+	ErrJSONDecoder    = errors.New("JSON decoder error")
+	ErrInvalidHookURL = errors.New("invalid hook url, want host:port")
 )
 
+// SplitHookURL 解析 tcp hook 地址 host:port，端口需在 1-65535 之间
+func SplitHookURL(hookURL string) (host string, port int, err error) {
+	host, portStr, err := net.SplitHostPort(hookURL)
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: %q: %w", ErrInvalidHookURL, hookURL, err)
+	}
+	port, err = strconv.Atoi(portStr)
+	if err != nil || port <= 0 || port > 65535 {
+		return "", 0, fmt.Errorf("%w: %q: illegal port", ErrInvalidHookURL, hookURL)
+	}
+	if host == "" {
+		return "", 0, fmt.Errorf("%w: %q: empty host", ErrInvalidHookURL, hookURL)
+	}
+	return host, port, nil
+}
+
 func NewWxClient(WxApiBaseUrl string, tcpHookURL string) *WxClient {
 	// api请求封装
 	return &WxClient{transport: NewTransport(WxApiBaseUrl, tcpHookURL)}
 }
 
+// SetHTTPClient 设置请求 wxhelper 使用的 http.Client
+func (c *WxClient) SetHTTPClient(httpClient *http.Client) {
+	c.transport.HTTPClient = httpClient
+}
+
+// SetTimeout 设置单次接口调用的超时，0 表示不限制
+func (c *WxClient) SetTimeout(timeout time.Duration) {
+	c.transport.Timeout = timeout
+}
+
+// SetRetryPolicy 设置接口调用的重试策略
+func (c *WxClient) SetRetryPolicy(policy RetryPolicy) {
+	c.transport.Retry = policy
+}
+
+// SetObserver 设置接口调用的回调，用于指标、追踪
+func (c *WxClient) SetObserver(observer CallObserver) {
+	c.transport.Observer = observer
+}
+
 func (c *WxClient) CheckLogin(ctx context.Context) (bool, error) {
-	resp, err := c.transport.CheckLogin(ctx)
+	r, err := call[none, any](ctx, c.transport, EndpointCheckLogin, nil)
 	if err != nil {
-		return false, fmt.Errorf("%w: %w", ErrWxClientResp, err)
-	}
-	defer func() { _ = resp.Body.Close() }()
-	var r result[any]
-	if err = json.NewDecoder(resp.Body).Decode(&r); err != nil {
-		return false, fmt.Errorf("%w: %w", ErrJSONDecoder, err)
+		return false, err
 	}
 	return r.Code == 1, nil
 }
 
 func (c *WxClient) GetUserInfo(ctx context.Context) (*models.Account, error) {
-	resp, err := c.transport.GetUserInfo(ctx)
+	r, err := call[none, *models.Account](ctx, c.transport, EndpointUserInfo, nil)
 	if err != nil {
-		return nil, fmt.Errorf("%w: %w", ErrWxClientResp, err)
-	}
-	defer func() { _ = resp.Body.Close() }()
-	var r result[*models.Account]
-	if err = json.NewDecoder(resp.Body).Decode(&r); err != nil {
-		return nil, fmt.Errorf("%w: %w", ErrJSONDecoder, err)
-	}
-	if r.Code != 1 {
-		return nil, errors.New("get user info failed")
+		return nil, err
 	}
 	return r.Data, nil
 }
 
-func (c *WxClient) handleResponse(resp *http.Response, expectedCode int) error {
-	defer func() { _ = resp.Body.Close() }()
-	var r result[any]
-	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
-		return fmt.Errorf("json decoder error: %w", err)
-	}
-	if r.Code != expectedCode {
-		return fmt.Errorf("unexpected response code: %d, msg: %s", r.Code, r.Msg)
-	}
-	return nil
-}
-
 func (c *WxClient) SendText(ctx context.Context, to string, content string) error {
-	resp, err := c.transport.SendText(ctx, to, content)
-	if err != nil {
-		return fmt.Errorf("send text failed: %w", err)
-	}
-	return c.handleResponse(resp, 0)
+	_, err := call[sendTextRequest, any](ctx, c.transport, EndpointSendText, &sendTextRequest{Wxid: to, Msg: content})
+	return err
 }
 
 func (c *WxClient) GetContactList(ctx context.Context) (models.Members, error) {
-	resp, err := c.transport.GetContactList(ctx)
+	r, err := call[none, models.Members](ctx, c.transport, EndpointGetContactList, nil)
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
-	var r result[models.Members]
-	if err = json.NewDecoder(resp.Body).Decode(&r); err != nil {
-		return nil, err
-	}
 	return r.Data, nil
 }
 
@@ -102,146 +106,74 @@ func (c *WxClient) HTTPHookSyncMsg(ctx context.Context, url *url.URL, timeout ti
 		Ip:         url.Hostname(),
 		Port:       url.Port(),
 	}
-	resp, err := c.transport.HookSyncMsg(ctx, opt)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = resp.Body.Close() }()
-	var r result[any]
-	if err = json.NewDecoder(resp.Body).Decode(&r); err != nil {
-
+	if err := c.unhook(ctx); err != nil { // 每次上报hook先unhook
 		return err
 	}
-	if r.Code != 0 {
-		return errors.New("hook sync msg failed")
-	}
-	return nil
+	_, err := call[TransportHookSyncMsgOption, any](ctx, c.transport, EndpointHookSyncMsg, &opt)
+	return err
 }
 
 func (c *WxClient) HookSyncMsg(ctx context.Context) error {
-	hookUrl := strings.Split(c.transport.TcpHookURL, colonSeparator)
-	ip := hookUrl[0]
-	port, err := strconv.Atoi(hookUrl[1])
+	ip, port, err := SplitHookURL(c.transport.TcpHookURL)
 	if err != nil {
-		return fmt.Errorf("hook_port ilegal: %w", err)
+		return err
 	}
 	opt := TransportHookSyncMsgOption{
 		EnableHttp: false,
 		Ip:         ip,
 		Port:       strconv.Itoa(port),
 	}
-	_, err = c.transport.UnhookSyncMsg(ctx) // 每次上报hook先unhook
-	if err != nil {
-		return err
-	}
-	resp, err := c.transport.HookSyncMsg(ctx, opt)
-	if err != nil {
+	if err = c.unhook(ctx); err != nil { // 每次上报hook先unhook
 		return err
 	}
-	defer func() { _ = resp.Body.Close() }()
-	var r result[any]
-	if err = json.NewDecoder(resp.Body).Decode(&r); err != nil {
+	_, err = call[TransportHookSyncMsgOption, any](ctx, c.transport, EndpointHookSyncMsg, &opt)
+	return err
+}
+
+// unhook 取消hook，只关心请求是否送达，忽略返回（未hook时同样视为成功）
+func (c *WxClient) unhook(ctx context.Context) error {
+	if err := c.UnhookSyncMsg(ctx); errors.Is(err, ErrRequest) {
 		return err
 	}
-	if r.Code != 0 {
-		return errors.New("hook sync msg failed")
-	}
 	return nil
 }
 
 func (c *WxClient) UnhookSyncMsg(ctx context.Context) error {
-	resp, err := c.transport.UnhookSyncMsg(ctx)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = resp.Body.Close() }()
-	var r result[any]
-	if err = json.NewDecoder(resp.Body).Decode(&r); err != nil {
-		return err
-	}
-	if r.Code != 0 {
-		return errors.New("unhook sync msg failed")
-	}
-	return nil
+	_, err := call[none, any](ctx, c.transport, EndpointUnhookSyncMsg, nil)
+	return err
 }
 
 func (c *WxClient) SendImage(ctx context.Context, to string, imgPath string) error {
-	resp, err := c.transport.SendImage(ctx, to, imgPath)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = resp.Body.Close() }()
-	var r result[any]
-	if err = json.NewDecoder(resp.Body).Decode(&r); err != nil {
-		return err
-	}
-	if r.Code != 1 {
-		return errors.New(r.Msg)
-	}
-	return nil
+	_, err := call[sendImageRequest, any](ctx, c.transport, EndpointSendImage, &sendImageRequest{Wxid: to, ImagePath: imgPath})
+	return err
 }
 
 func (c *WxClient) SendFile(ctx context.Context, to string, filePath string) error {
-	resp, err := c.transport.SendFile(ctx, to, filePath)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = resp.Body.Close() }()
-	var r result[any]
-	if err = json.NewDecoder(resp.Body).Decode(&r); err != nil {
-		return err
-	}
-	if r.Code == 0 {
-		return fmt.Errorf("send file failed with code %d", r.Code)
-	}
-	return nil
+	_, err := call[sendFileRequest, any](ctx, c.transport, EndpointSendFile, &sendFileRequest{Wxid: to, FilePath: filePath})
+	return err
 }
 
 func (c *WxClient) GetChatRoomDetail(ctx context.Context, chatRoomId string) (*models.ChatRoomInfo, error) {
-	resp, err := c.transport.GetChatRoomDetail(ctx, chatRoomId)
+	r, err := call[chatRoomRequest, models.ChatRoomInfo](ctx, c.transport, EndpointGetChatRoomDetail, &chatRoomRequest{ChatRoomId: chatRoomId})
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
-	var r result[models.ChatRoomInfo]
-	if err = json.NewDecoder(resp.Body).Decode(&r); err != nil {
-		return nil, err
-	}
-	if r.Code != 1 {
-		return nil, errors.New("get chat room detail failed")
-	}
 	return &r.Data, nil
 }
 
 func (c *WxClient) GetMemberFromChatRoom(ctx context.Context, chatRoomId string) (*models.GroupMember, error) {
-	resp, err := c.transport.GetMemberFromChatRoom(ctx, chatRoomId)
+	r, err := call[chatRoomRequest, models.GroupMember](ctx, c.transport, EndpointGetMemberFromChatRoom, &chatRoomRequest{ChatRoomId: chatRoomId})
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
-	var r result[models.GroupMember]
-	if err = json.NewDecoder(resp.Body).Decode(&r); err != nil {
-		return nil, err
-	}
-	if r.Code != 1 {
-		return nil, errors.New("get chat room member failed")
-	}
 	return &r.Data, nil
 }
 
 func (c *WxClient) GetContactProfile(ctx context.Context, wxid string) (*models.Profile, error) {
-	resp, err := c.transport.GetContactProfile(ctx, wxid)
+	r, err := call[wxidRequest, models.Profile](ctx, c.transport, EndpointGetContactProfile, &wxidRequest{Wxid: wxid})
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
-	var r result[models.Profile]
-	if err = json.NewDecoder(resp.Body).Decode(&r); err != nil {
-		return nil, err
-	}
-	if r.Code < 0 {
-		return nil, errors.New("get contact profile failed")
-	}
 	return &r.Data, nil
 }
 
@@ -252,85 +184,97 @@ type SendAtTextOption struct {
 }
 
 func (c *WxClient) SendAtText(ctx context.Context, opt SendAtTextOption) error {
-	resp, err := c.transport.SendAtText(ctx, sendAtTextOption{
+	_, err := call[sendAtTextOption, any](ctx, c.transport, EndpointSendAtText, &sendAtTextOption{
 		WxIds:      strings.Join(opt.WxIds, ","),
 		ChatRoomId: opt.ChatRoomID,
 		Msg:        opt.Content,
 	})
-	if err != nil {
-		return err
-	}
-	defer func() { _ = resp.Body.Close() }()
-	var r result[any]
-	if err = json.NewDecoder(resp.Body).Decode(&r); err != nil {
-		return err
-	}
-	if r.Code < 0 {
-		return errors.New("send at text failed")
-	}
-	return nil
+	return err
 }
 
 func (c *WxClient) AddMemberIntoChatRoom(ctx context.Context, chatRoomID string, memberIDs []string) error {
-	resp, err := c.transport.AddMemberIntoChatRoom(ctx, chatRoomID, strings.Join(memberIDs, ","))
-	if err != nil {
-		return err
-	}
-	defer func() { _ = resp.Body.Close() }()
-	var r result[any]
-	if err = json.NewDecoder(resp.Body).Decode(&r); err != nil {
-		return err
-	}
-	if r.Code != 1 {
-		return errors.New("add member into chat room failed")
-	}
-	return nil
+	req := &chatRoomMembersRequest{ChatRoomId: chatRoomID, MemberIds: strings.Join(memberIDs, ",")}
+	_, err := call[chatRoomMembersRequest, any](ctx, c.transport, EndpointAddMemberToChatRoom, req)
+	return err
 }
 
 func (c *WxClient) InviteMemberToChatRoom(ctx context.Context, chatRoomID string, memberIDs []string) error {
-	resp, err := c.transport.InviteMemberToChatRoom(ctx, chatRoomID, strings.Join(memberIDs, ","))
-	if err != nil {
-		return err
-	}
-	defer func() { _ = resp.Body.Close() }()
-	var r result[any]
-	if err = json.NewDecoder(resp.Body).Decode(&r); err != nil {
-		return err
-	}
-	if r.Code != 1 {
-		return errors.New("invite member into chat room failed")
-	}
-	return nil
+	req := &chatRoomMembersRequest{ChatRoomId: chatRoomID, MemberIds: strings.Join(memberIDs, ",")}
+	_, err := call[chatRoomMembersRequest, any](ctx, c.transport, EndpointInviteMemberToChatRoom, req)
+	return err
+}
+
+// DelMemberFromChatRoom 移出群成员
+func (c *WxClient) DelMemberFromChatRoom(ctx context.Context, chatRoomID string, memberIDs []string) error {
+	req := &chatRoomMembersRequest{ChatRoomId: chatRoomID, MemberIds: strings.Join(memberIDs, ",")}
+	_, err := call[chatRoomMembersRequest, any](ctx, c.transport, EndpointDelMemberFromChatRoom, req)
+	return err
+}
+
+// ModifyNickname 修改群昵称 <wxid: 自己的 wxid>
+func (c *WxClient) ModifyNickname(ctx context.Context, chatRoomID, wxid, nickname string) error {
+	req := &modifyNicknameRequest{ChatRoomId: chatRoomID, Wxid: wxid, NickName: nickname}
+	_, err := call[modifyNicknameRequest, any](ctx, c.transport, EndpointModifyNickname, req)
+	return err
 }
 
 func (c *WxClient) ForwardMsg(ctx context.Context, msgID, wxID string) error {
-	resp, err := c.transport.ForwardMsg(ctx, msgID, wxID)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = resp.Body.Close() }()
-	var r result[any]
-	if err = json.NewDecoder(resp.Body).Decode(&r); err != nil {
-		return err
-	}
-	if r.Code != 1 {
-		return errors.New("forward msg failed")
-	}
-	return nil
+	_, err := call[forwardMsgRequest, any](ctx, c.transport, EndpointForwardMsg, &forwardMsgRequest{Wxid: wxID, MsgId: msgID})
+	return err
 }
 
 func (c *WxClient) QuitChatRoom(ctx context.Context, chatRoomId string) error {
-	resp, err := c.transport.QuitChatRoom(ctx, chatRoomId)
+	_, err := call[chatRoomRequest, any](ctx, c.transport, EndpointQuitChatRoom, &chatRoomRequest{ChatRoomId: chatRoomId})
+	return err
+}
+
+// SendPatMsg 拍一拍 <receiver: 群 id 或好友 wxid>, <wxid: 被拍的 wxid>
+func (c *WxClient) SendPatMsg(ctx context.Context, receiver, wxid string) error {
+	_, err := call[patRequest, any](ctx, c.transport, EndpointSendPatMsg, &patRequest{Receiver: receiver, Wxid: wxid})
+	return err
+}
+
+// SendCustomEmotion 发送表情 <filePath: wxhelper 所在机器上的表情文件路径>
+func (c *WxClient) SendCustomEmotion(ctx context.Context, to, filePath string) error {
+	_, err := call[sendFileRequest, any](ctx, c.transport, EndpointSendCustomEmotion, &sendFileRequest{Wxid: to, FilePath: filePath})
+	return err
+}
+
+// TopMsg 置顶群消息
+func (c *WxClient) TopMsg(ctx context.Context, msgID int64) error {
+	_, err := call[msgIdRequest, any](ctx, c.transport, EndpointTopMsg, &msgIdRequest{MsgId: msgID})
+	return err
+}
+
+// RemoveTopMsg 取消置顶群消息
+func (c *WxClient) RemoveTopMsg(ctx context.Context, msgID int64) error {
+	_, err := call[msgIdRequest, any](ctx, c.transport, EndpointRemoveTopMsg, &msgIdRequest{MsgId: msgID})
+	return err
+}
+
+// DownloadAttach 下载消息附件（图片、视频、文件）到 wxhelper 所在机器
+func (c *WxClient) DownloadAttach(ctx context.Context, msgID int64) error {
+	_, err := call[msgIdRequest, any](ctx, c.transport, EndpointDownloadAttach, &msgIdRequest{MsgId: msgID})
+	return err
+}
+
+// DecodeImage 解码 .dat 图片 <filePath: 图片路径>, <storeDir: 保存目录>，路径均为 wxhelper 所在机器上的路径
+func (c *WxClient) DecodeImage(ctx context.Context, filePath, storeDir string) error {
+	_, err := call[storeRequest, any](ctx, c.transport, EndpointDecodeImage, &storeRequest{FilePath: filePath, StoreDir: storeDir})
+	return err
+}
+
+// GetVoiceByMsgId 保存语音消息 <storeDir: wxhelper 所在机器上的保存目录>
+func (c *WxClient) GetVoiceByMsgId(ctx context.Context, msgID int64, storeDir string) error {
+	_, err := call[storeRequest, any](ctx, c.transport, EndpointGetVoiceByMsgId, &storeRequest{MsgId: msgID, StoreDir: storeDir})
+	return err
+}
+
+// OCR 识别图片中的文字 <imagePath: wxhelper 所在机器上的图片路径>
+func (c *WxClient) OCR(ctx context.Context, imagePath string) (string, error) {
+	r, err := call[ocrRequest, string](ctx, c.transport, EndpointOCR, &ocrRequest{ImagePath: imagePath})
 	if err != nil {
-		return err
-	}
-	defer func() { _ = resp.Body.Close() }()
-	var r result[any]
-	if err = json.NewDecoder(resp.Body).Decode(&r); err != nil {
-		return err
+		return "", err
 	}
-	if r.Code <= 0 {
-		return errors.New("quit chatroom failed")
-	}
-	return nil
+	return r.Data, nil
 }
@@ -0,0 +1,144 @@
+// Package inner
+// @Author Clover
+// @Data 2025/1/18 下午2:00:00
+// @Desc wxhelper 接口表与通用请求
+package inner
+
+import (
+	"context"
+	"time"
+)
+
+// wxhelper 接口路径
+const (
+	EndpointCheckLogin             = "/api/checkLogin"
+	EndpointUserInfo               = "/api/userInfo"
+	EndpointSendText               = "/api/sendTextMsg"
+	EndpointSendImage              = "/api/sendImagesMsg"
+	EndpointSendFile               = "/api/sendFileMsg"
+	EndpointGetContactList         = "/api/getContactList"
+	EndpointHookSyncMsg            = "/api/hookSyncMsg"
+	EndpointUnhookSyncMsg          = "/api/unhookSyncMsg"
+	EndpointGetChatRoomDetail      = "/api/getChatRoomDetailInfo"
+	EndpointModifyNickname         = "/api/modifyNickname"
+	EndpointDelMemberFromChatRoom  = "/api/delMemberFromChatRoom"
+	EndpointGetMemberFromChatRoom  = "/api/getMemberFromChatRoom"
+	EndpointGetContactProfile      = "/api/getContactProfile"
+	EndpointSendAtText             = "/api/sendAtText"
+	EndpointAddMemberToChatRoom    = "/api/addMemberToChatRoom"
+	EndpointInviteMemberToChatRoom = "/api/InviteMemberToChatRoom"
+	EndpointForwardMsg             = "/api/forwardMsg"
+	EndpointQuitChatRoom           = "/api/quitChatRoom"
+	EndpointSendPatMsg             = "/api/sendPatMsg"
+	EndpointSendCustomEmotion      = "/api/sendCustomEmotion"
+	EndpointTopMsg                 = "/api/topMsg"
+	EndpointRemoveTopMsg           = "/api/removeTopMsg"
+	EndpointDownloadAttach         = "/api/downloadAttach"
+	EndpointDecodeImage            = "/api/decodeImage"
+	EndpointGetVoiceByMsgId        = "/api/getVoiceByMsgId"
+	EndpointOCR                    = "/api/ocr"
+)
+
+// endpointSpec 接口描述
+type endpointSpec struct {
+	idempotent bool                // 只读查询接口，失败后可安全重试
+	success    func(code int) bool // 表示成功的 result.Code，为空时不校验
+}
+
+// endpoints 各接口的描述，新增接口只需在此登记并通过 call 调用
+var endpoints = map[string]endpointSpec{
+	EndpointCheckLogin:             {idempotent: true},
+	EndpointUserInfo:               {idempotent: true, success: codeEq(1)},
+	EndpointSendText:               {success: codeEq(0)},
+	EndpointSendImage:              {success: codeEq(1)},
+	EndpointSendFile:               {success: func(code int) bool { return code != 0 }},
+	EndpointGetContactList:         {idempotent: true},
+	EndpointHookSyncMsg:            {success: codeEq(0)},
+	EndpointUnhookSyncMsg:          {success: codeEq(0)},
+	EndpointGetChatRoomDetail:      {idempotent: true, success: codeEq(1)},
+	EndpointModifyNickname:         {success: codeGt(0)},
+	EndpointDelMemberFromChatRoom:  {success: codeGt(0)},
+	EndpointGetMemberFromChatRoom:  {idempotent: true, success: codeEq(1)},
+	EndpointGetContactProfile:      {idempotent: true, success: func(code int) bool { return code >= 0 }},
+	EndpointSendAtText:             {success: func(code int) bool { return code >= 0 }},
+	EndpointAddMemberToChatRoom:    {success: codeEq(1)},
+	EndpointInviteMemberToChatRoom: {success: codeEq(1)},
+	EndpointForwardMsg:             {success: codeEq(1)},
+	EndpointQuitChatRoom:           {success: codeGt(0)},
+	EndpointSendPatMsg:             {success: codeGt(0)},
+	EndpointSendCustomEmotion:      {success: codeGt(0)},
+	EndpointTopMsg:                 {success: codeGt(0)},
+	EndpointRemoveTopMsg:           {success: codeGt(0)},
+	EndpointDownloadAttach:         {success: codeGt(0)},
+	EndpointDecodeImage:            {success: codeGt(0)},
+	EndpointGetVoiceByMsgId:        {success: codeGt(0)},
+	EndpointOCR:                    {success: codeEq(0)},
+}
+
+func codeEq(want int) func(code int) bool {
+	return func(code int) bool { return code == want }
+}
+
+func codeGt(min int) func(code int) bool {
+	return func(code int) bool { return code > min }
+}
+
+func isSuccess(endpoint string, code int) bool {
+	spec, ok := endpoints[endpoint]
+	return !ok || spec.success == nil || spec.success(code)
+}
+
+// IsIdempotent 接口是否可安全重试
+func IsIdempotent(endpoint string) bool {
+	return endpoints[endpoint].idempotent
+}
+
+// CallInfo 一次接口调用的信息，用于指标、追踪
+type CallInfo struct {
+	Endpoint   string
+	StatusCode int // http 状态码，未得到响应时为 0
+	Code       int // result.Code
+	Attempt    int // 第几次尝试，从 1 开始
+	Duration   time.Duration
+	Err        error
+}
+
+// CallObserver 每次接口调用结束后回调
+type CallObserver func(info CallInfo)
+
+// none 无请求体
+type none = struct{}
+
+// call 请求 endpoint 并按接口表解析返回，req 为 nil 时不带请求体
+// 每次尝试受 Transport.Timeout 限制，失败时按 Transport.Retry 重试（见 shouldRetry）
+func call[Req any, Resp any](ctx context.Context, t *Transport, endpoint string, req *Req) (result[Resp], error) {
+	var body any
+	if req != nil {
+		body = req
+	}
+	for attempt := 1; ; attempt++ {
+		r, err := callOnce[Resp](ctx, t, endpoint, body, attempt)
+		if err == nil || !t.shouldRetry(ctx, endpoint, attempt, err) || !t.wait(ctx, attempt) {
+			return r, err
+		}
+	}
+}
+
+func callOnce[Resp any](ctx context.Context, t *Transport, endpoint string, body any, attempt int) (result[Resp], error) {
+	if t.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.Timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	resp, err := t.Do(ctx, endpoint, body)
+	r, err := decode[Resp](endpoint, resp, err)
+	if t.Observer != nil {
+		info := CallInfo{Endpoint: endpoint, Code: r.Code, Attempt: attempt, Duration: time.Since(start), Err: err}
+		if resp != nil {
+			info.StatusCode = resp.StatusCode
+		}
+		t.Observer(info)
+	}
+	return r, err
+}
@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 	"wxhelper-sdk/inner/utils"
 )
 
@@ -25,9 +26,11 @@ var (
 )
 
 type ICacheManager interface {
+	Dir() string
 	Save(fileName string, isImg bool, data []byte) (*FileInfo, error)
 	GetFilePathByFileName(fileName string) (string, error)
 	GetDataByFileName(fileName string) ([]byte, error)
+	Purge(before time.Time) (int, error)
 }
 
 type FileName2FileInfo map[string]*FileInfo // FileName-to-FileInfo mapping
@@ -43,6 +46,7 @@ type FileInfo struct {
 type CacheManager struct {
 	mu                sync.RWMutex
 	fileName2FileInfo FileName2FileInfo
+	dir               string // 缓存根目录，为空时使用 utils.TempDir()
 }
 
 var (
@@ -57,6 +61,22 @@ func newCacheManager(cacheSize int) *CacheManager {
 	}
 }
 
+// NewCacheManager creates a CacheManager rooted at dir, so that several clients
+// in one process do not share files. An empty dir falls back to utils.TempDir().
+func NewCacheManager(dir string) ICacheManager {
+	cm := newCacheManager(30)
+	cm.dir = dir
+	return cm
+}
+
+// Dir returns the root directory of the cache.
+func (cm *CacheManager) Dir() string {
+	if cm.dir == "" {
+		return utils.TempDir()
+	}
+	return cm.dir
+}
+
 // GetCacheManager returns the singleton instance of CacheManager.
 func GetCacheManager() ICacheManager {
 	cacheManagerOnce.Do(func() {
@@ -76,7 +96,7 @@ func (cm *CacheManager) Save(fileName string, isImg bool, data []byte) (*FileInf
 	}
 
 	// Generate file path
-	filePath, err := utils.ConvertToWindows(fileName, isImg)
+	filePath, err := utils.ConvertToWindowsIn(cm.Dir(), fileName, isImg)
 	if err != nil {
 		return nil, fmt.Errorf("convert to windows file failed: %w", err)
 	}
@@ -128,6 +148,38 @@ func (cm *CacheManager) GetDataByFileName(fileName string) ([]byte, error) {
 	return data, nil
 }
 
+// Purge removes cached files last modified before the given time and returns how many were removed.
+func (cm *CacheManager) Purge(before time.Time) (int, error) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	var (
+		removed int
+		errs    []error
+	)
+	for fileName, fileInfo := range cm.fileName2FileInfo {
+		stat, err := os.Stat(fileInfo.FilePath)
+		if errors.Is(err, os.ErrNotExist) {
+			delete(cm.fileName2FileInfo, fileName)
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !stat.ModTime().Before(before) {
+			continue
+		}
+		if err = os.Remove(fileInfo.FilePath); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		delete(cm.fileName2FileInfo, fileName)
+		removed++
+	}
+	return removed, errors.Join(errs...)
+}
+
 // writeDataToFile writes data to the given file path.
 func writeDataToFile(filePath string, data []byte) error {
 	if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
@@ -150,3 +150,37 @@ func TestTempDir(t *testing.T) {
 		t.Errorf("expected %s, got %s", expectedTempDir, tempDir)
 	}
 }
+
+// TestCacheManagerPurge 测试按保留时间清理缓存
+func TestCacheManagerPurge(t *testing.T) {
+	manager := NewCacheManager(t.TempDir())
+
+	oldInfo, err := manager.Save("old.txt", false, []byte("old"))
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err = manager.Save("new.txt", false, []byte("new")); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	past := time.Now().Add(-2 * time.Hour)
+	if err = os.Chtimes(oldInfo.FilePath, past, past); err != nil {
+		t.Fatalf("Chtimes failed: %v", err)
+	}
+
+	removed, err := manager.Purge(time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("Purge failed: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("expected 1 file removed, got %d", removed)
+	}
+	if _, err = manager.GetFilePathByFileName("old.txt"); !os.IsNotExist(err) {
+		t.Errorf("expected old.txt purged, got %v", err)
+	}
+	if _, err = os.Stat(oldInfo.FilePath); !os.IsNotExist(err) {
+		t.Errorf("expected old file removed from disk, got %v", err)
+	}
+	if _, err = manager.GetFilePathByFileName("new.txt"); err != nil {
+		t.Errorf("expected new.txt kept, got %v", err)
+	}
+}
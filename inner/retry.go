@@ -0,0 +1,94 @@
+// Package inner
+// @Author Clover
+// @Data 2025/1/19 上午10:30:00
+// @Desc 接口调用的超时与重试
+package inner
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+const DefaultTimeout = 10 * time.Second
+
+// RetryPolicy 重试策略，仅对幂等接口或通过 WithRetry 显式允许的调用生效
+type RetryPolicy struct {
+	MaxAttempts    int           // 最多尝试次数（含首次），<= 1 表示不重试
+	InitialBackoff time.Duration // 首次重试前的等待
+	MaxBackoff     time.Duration // 等待的上限
+	Multiplier     float64       // 每次重试等待的增长倍数
+	Jitter         float64       // 随机抖动比例 [0, 1]，等待时间在 backoff*(1±Jitter) 之间
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 200 * time.Millisecond,
+	MaxBackoff:     2 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+// backoff 第 attempt 次失败后的等待时间
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		d *= p.Multiplier
+		if p.MaxBackoff > 0 && d >= float64(p.MaxBackoff) {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		d *= 1 - p.Jitter + 2*p.Jitter*rand.Float64()
+	}
+	return time.Duration(d)
+}
+
+type retryKey struct{}
+
+// WithRetry 允许该 ctx 下的非幂等调用（如发送消息）按重试策略重试，可能导致重复发送
+func WithRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryKey{}, true)
+}
+
+func retryAllowed(ctx context.Context, endpoint string) bool {
+	if IsIdempotent(endpoint) {
+		return true
+	}
+	allowed, _ := ctx.Value(retryKey{}).(bool)
+	return allowed
+}
+
+// retryable 请求失败（含单次超时）或 wxhelper 返回 5xx、429 时可重试，返回码不符不重试
+func retryable(err error) bool {
+	if errors.Is(err, ErrRequest) {
+		return true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && errors.Is(err, ErrUnexpectedStatus) {
+		return apiErr.StatusCode >= http.StatusInternalServerError || apiErr.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// shouldRetry 第 attempt 次调用失败后是否继续重试
+func (c *Transport) shouldRetry(ctx context.Context, endpoint string, attempt int, err error) bool {
+	return attempt < c.Retry.MaxAttempts && ctx.Err() == nil && retryable(err) && retryAllowed(ctx, endpoint)
+}
+
+// wait 等待重试，ctx 结束时返回 false
+func (c *Transport) wait(ctx context.Context, attempt int) bool {
+	timer := time.NewTimer(c.Retry.backoff(attempt))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
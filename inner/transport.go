@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	urlpkg "net/url"
+	"time"
 )
 
 type TransportHookSyncMsgOption struct {
@@ -22,350 +24,102 @@ type sendAtTextOption struct {
 	Msg        string `json:"msg"`
 }
 
-type Transport struct {
-	BaseURL    string
-	TcpHookURL string // default: 127.0.0.1:19089 (对WxApi发送tcpHookServer地址)
+type sendTextRequest struct {
+	Wxid string `json:"wxid"`
+	Msg  string `json:"msg"`
 }
 
-// NewTransport 新建消息传输模块 <baseURL:API http 地址>, <tcpHookURL: tcpHook地址>
-// todo 可支持HTTP—Hook
-func NewTransport(baseURL string, tcpHookURL string) *Transport {
-	return &Transport{BaseURL: baseURL, TcpHookURL: tcpHookURL}
+type sendImageRequest struct {
+	Wxid      string `json:"wxid"`
+	ImagePath string `json:"imagePath"`
 }
 
-func (c *Transport) CheckLogin(ctx context.Context) (*http.Response, error) {
-	url, err := urlpkg.Parse(c.BaseURL + "/api/checkLogin")
-	if err != nil {
-		return nil, err
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url.String(), nil)
-	if err != nil {
-		return nil, err
-	}
-	return http.DefaultClient.Do(req)
+type sendFileRequest struct {
+	Wxid     string `json:"wxid"`
+	FilePath string `json:"filePath"`
 }
 
-func (c *Transport) GetUserInfo(ctx context.Context) (*http.Response, error) {
-	url, err := urlpkg.Parse(c.BaseURL + "/api/userInfo")
-	if err != nil {
-		return nil, err
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url.String(), nil)
-	if err != nil {
-		return nil, err
-	}
-	return http.DefaultClient.Do(req)
+type forwardMsgRequest struct {
+	Wxid  string `json:"wxid"`
+	MsgId string `json:"msgId"`
 }
 
-func (c *Transport) SendText(ctx context.Context, to string, content string) (*http.Response, error) {
-	url, err := urlpkg.Parse(c.BaseURL + "/api/sendTextMsg")
-	if err != nil {
-		return nil, err
-	}
-	var payload = map[string]string{
-		"wxid": to,
-		"msg":  content,
-	}
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return nil, err
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url.String(), bytes.NewReader(data))
-	if err != nil {
-		return nil, err
-	}
-	return http.DefaultClient.Do(req)
-}
-
-func (c *Transport) ForwardMessage(ctx context.Context, to, msgID string) (*http.Response, error) {
-	url, err := urlpkg.Parse(c.BaseURL + "/api/forwardMessage")
-	if err != nil {
-		return nil, err
-	}
-	var payload = map[string]string{
-		"wxid":  to,
-		"msgid": msgID,
-	}
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return nil, err
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url.String(), bytes.NewReader(data))
-	if err != nil {
-		return nil, err
-	}
-	return http.DefaultClient.Do(req)
-}
-
-func (c *Transport) SendImage(ctx context.Context, to, imagePath string) (*http.Response, error) {
-	url, err := urlpkg.Parse(c.BaseURL + "/api/sendImagesMsg")
-	if err != nil {
-		return nil, err
-	}
-	var payload = map[string]string{
-		"wxid":      to,
-		"imagePath": imagePath,
-	}
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return nil, err
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url.String(), bytes.NewReader(data))
-	if err != nil {
-		return nil, err
-	}
-	return http.DefaultClient.Do(req)
-}
-
-func (c *Transport) SendFile(ctx context.Context, to, filePath string) (*http.Response, error) {
-	url, err := urlpkg.Parse(c.BaseURL + "/api/sendFileMsg")
-	if err != nil {
-		return nil, err
-	}
-	var payload = map[string]string{
-		"wxid":     to,
-		"filePath": filePath,
-	}
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return nil, err
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url.String(), bytes.NewReader(data))
-	if err != nil {
-		return nil, err
-	}
-	return http.DefaultClient.Do(req)
-}
-
-func (c *Transport) GetContactList(ctx context.Context) (*http.Response, error) {
-	url, err := urlpkg.Parse(c.BaseURL + "/api/getContactList")
-	if err != nil {
-		return nil, err
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url.String(), nil)
-	if err != nil {
-		return nil, err
-	}
-	return http.DefaultClient.Do(req)
-}
-
-func (c *Transport) HookSyncMsg(ctx context.Context, opt TransportHookSyncMsgOption) (*http.Response, error) {
-	url, err := urlpkg.Parse(c.BaseURL + "/api/hookSyncMsg")
-	if err != nil {
-		return nil, err
-	}
-	data, err := json.Marshal(opt)
-	if err != nil {
-		return nil, err
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url.String(), bytes.NewReader(data))
-	if err != nil {
-		return nil, err
-	}
-	return http.DefaultClient.Do(req)
+type chatRoomRequest struct {
+	ChatRoomId string `json:"chatRoomId"`
 }
 
-func (c *Transport) UnhookSyncMsg(ctx context.Context) (*http.Response, error) {
-	url, err := urlpkg.Parse(c.BaseURL + "/api/unhookSyncMsg")
-	if err != nil {
-		return nil, err
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url.String(), nil)
-	if err != nil {
-		return nil, err
-	}
-	return http.DefaultClient.Do(req)
+type chatRoomMembersRequest struct {
+	ChatRoomId string `json:"chatRoomId"`
+	MemberIds  string `json:"memberIds"` // 多个 wxid 以 , 分隔
 }
 
-func (c *Transport) GetChatRoomDetail(ctx context.Context, chatRoomId string) (*http.Response, error) {
-	url, err := urlpkg.Parse(c.BaseURL + "/api/getChatRoomDetailInfo")
-	if err != nil {
-		return nil, err
-	}
-	var payload = map[string]string{
-		"chatRoomId": chatRoomId,
-	}
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return nil, err
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url.String(), bytes.NewReader(data))
-	if err != nil {
-		return nil, err
-	}
-	return http.DefaultClient.Do(req)
+type modifyNicknameRequest struct {
+	ChatRoomId string `json:"chatRoomId"`
+	Wxid       string `json:"wxid"`
+	NickName   string `json:"nickName"`
 }
 
-func (c *Transport) ModifyNickname(ctx context.Context, chatRoomId, wxId, nickname string) (*http.Response, error) {
-	url, err := urlpkg.Parse(c.BaseURL + "/api/modifyNickname")
-	if err != nil {
-		return nil, err
-	}
-	var payload = map[string]string{
-		"chatRoomId": chatRoomId,
-		"wxid":       wxId,
-		"nickName":   nickname,
-	}
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return nil, err
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url.String(), bytes.NewReader(data))
-	if err != nil {
-		return nil, err
-	}
-	return http.DefaultClient.Do(req)
+type wxidRequest struct {
+	Wxid string `json:"wxid"`
 }
 
-func (c *Transport) DelMemberFromChatRoom(ctx context.Context, chatRoomId string, memberIds ...string) (*http.Response, error) {
-	url, err := urlpkg.Parse(c.BaseURL + "/api/delMemberFromChatRoom")
-	if err != nil {
-		return nil, err
-	}
-	var payload = map[string]interface{}{
-		"chatRoomId": chatRoomId,
-		"memberIds":  memberIds,
-	}
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return nil, err
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url.String(), bytes.NewReader(data))
-	if err != nil {
-		return nil, err
-	}
-	return http.DefaultClient.Do(req)
+type patRequest struct {
+	Receiver string `json:"receiver"` // 群 id 或好友 wxid
+	Wxid     string `json:"wxid"`     // 被拍的 wxid
 }
 
-func (c *Transport) GetMemberFromChatRoom(ctx context.Context, chatRoomId string) (*http.Response, error) {
-	url, err := urlpkg.Parse(c.BaseURL + "/api/getMemberFromChatRoom")
-	if err != nil {
-		return nil, err
-	}
-	var payload = map[string]string{
-		"chatRoomId": chatRoomId,
-	}
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return nil, err
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url.String(), bytes.NewReader(data))
-	if err != nil {
-		return nil, err
-	}
-	return http.DefaultClient.Do(req)
+type msgIdRequest struct {
+	MsgId int64 `json:"msgId"`
 }
 
-func (c *Transport) GetContactProfile(ctx context.Context, wxid string) (*http.Response, error) {
-	url, err := urlpkg.Parse(c.BaseURL + "/api/getContactProfile")
-	if err != nil {
-		return nil, err
-	}
-	var payload = map[string]string{
-		"wxid": wxid,
-	}
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return nil, err
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url.String(), bytes.NewReader(data))
-	if err != nil {
-		return nil, err
-	}
-	return http.DefaultClient.Do(req)
+type storeRequest struct {
+	MsgId    int64  `json:"msgId,omitempty"`
+	FilePath string `json:"filePath,omitempty"`
+	StoreDir string `json:"storeDir"`
 }
 
-func (c *Transport) SendAtText(ctx context.Context, option sendAtTextOption) (*http.Response, error) {
-	url, err := urlpkg.Parse(c.BaseURL + "/api/sendAtText")
-	if err != nil {
-		return nil, err
-	}
-	data, err := json.Marshal(option)
-	if err != nil {
-		return nil, err
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url.String(), bytes.NewReader(data))
-	if err != nil {
-		return nil, err
-	}
-	return http.DefaultClient.Do(req)
+type ocrRequest struct {
+	ImagePath string `json:"imagePath"`
 }
 
-func (c *Transport) AddMemberIntoChatRoom(ctx context.Context, chatRoomId string, memberIds string) (*http.Response, error) {
-	url, err := urlpkg.Parse(c.BaseURL + "/api/addMemberToChatRoom")
-	if err != nil {
-		return nil, err
-	}
-	var payload = map[string]string{
-		"chatRoomId": chatRoomId,
-		"memberIds":  memberIds,
-	}
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return nil, err
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url.String(), bytes.NewReader(data))
-	if err != nil {
-		return nil, err
-	}
-	return http.DefaultClient.Do(req)
+type Transport struct {
+	BaseURL    string
+	TcpHookURL string        // default: 127.0.0.1:19089 (对WxApi发送tcpHookServer地址)
+	HTTPClient *http.Client  // 为空时使用 http.DefaultClient
+	Observer   CallObserver  // 每次接口调用结束后回调，为空时不回调
+	Timeout    time.Duration // 单次调用的超时，0 表示不限制
+	Retry      RetryPolicy   // 幂等接口的重试策略
 }
 
-func (c *Transport) InviteMemberToChatRoom(ctx context.Context, chatRoomId string, memberIds string) (*http.Response, error) {
-	url, err := urlpkg.Parse(c.BaseURL + "/api/InviteMemberToChatRoom")
-	if err != nil {
-		return nil, err
-	}
-	var payload = map[string]string{
-		"chatRoomId": chatRoomId,
-		"memberIds":  memberIds,
-	}
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return nil, err
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url.String(), bytes.NewReader(data))
-	if err != nil {
-		return nil, err
+func (c *Transport) client() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
 	}
-	return http.DefaultClient.Do(req)
+	return http.DefaultClient
 }
 
-func (c *Transport) ForwardMsg(ctx context.Context, msgID, wxID string) (*http.Response, error) {
-	url, err := urlpkg.Parse(c.BaseURL + "/api/forwardMsg")
-	if err != nil {
-		return nil, err
-	}
-	var payload = map[string]string{
-		"wxid":  wxID,
-		"msgId": msgID,
-	}
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return nil, err
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url.String(), bytes.NewReader(data))
-	if err != nil {
-		return nil, err
-	}
-	return http.DefaultClient.Do(req)
+// NewTransport 新建消息传输模块 <baseURL:API http 地址>, <tcpHookURL: tcpHook地址>
+func NewTransport(baseURL string, tcpHookURL string) *Transport {
+	return &Transport{BaseURL: baseURL, TcpHookURL: tcpHookURL, Timeout: DefaultTimeout, Retry: DefaultRetryPolicy}
 }
 
-func (c *Transport) QuitChatRoom(ctx context.Context, chatRoomId string) (*http.Response, error) {
-	url, err := urlpkg.Parse(c.BaseURL + "/api/quitChatRoom")
+// Do 以 POST 方式请求 endpoint，body 以 json 编码，为 nil 时不带请求体
+func (c *Transport) Do(ctx context.Context, endpoint string, body any) (*http.Response, error) {
+	url, err := urlpkg.Parse(c.BaseURL + endpoint)
 	if err != nil {
 		return nil, err
 	}
-	var payload = map[string]string{
-		"chatRoomId": chatRoomId,
-	}
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return nil, err
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url.String(), bytes.NewReader(data))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url.String(), reader)
 	if err != nil {
 		return nil, err
 	}
-	return http.DefaultClient.Do(req)
+	return c.client().Do(req)
 }
@@ -28,6 +28,11 @@ func TempDir() string {
 // string: 转换后的 Windows 文件路径。
 // error: 如果获取临时目录或创建目录时出错，则返回错误。
 func ConvertToWindows(fileName string, isImg bool) (string, error) {
+	return ConvertToWindowsIn(TempDir(), fileName, isImg)
+}
+
+// ConvertToWindowsIn 与 ConvertToWindows 相同，但使用指定的根目录 <baseDir> 代替临时目录
+func ConvertToWindowsIn(baseDir string, fileName string, isImg bool) (string, error) {
 	// 根据 isImg 参数确定子目录
 	var subDir string
 	if isImg {
@@ -37,7 +42,7 @@ func ConvertToWindows(fileName string, isImg bool) (string, error) {
 	}
 
 	// 构建完整路径
-	dir := filepath.Join(TempDir(), subDir)
+	dir := filepath.Join(baseDir, subDir)
 
 	// 确保目录存在
 	err := os.MkdirAll(dir, os.ModePerm)
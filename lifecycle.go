@@ -0,0 +1,253 @@
+// Package wxhelper_sdk
+// @Author Clover
+// @Data 2025/1/14 上午11:20:00
+// @Desc 登录状态机：等待登录、掉线与换号检测
+package wxhelper_sdk
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// State 客户端登录状态
+type State int32
+
+const (
+	StateInit         State = iota // 尚未运行
+	StateWaitingLogin              // 等待微信登录
+	StateLoggedIn                  // 已登录
+	StateLoggedOut                 // 已掉线（微信退出或重启），等待重新登录
+	StateClosing                   // 正在关闭，GetMsg 仍可取走缓冲区中剩余的消息
+	StateClosed                    // 客户端已关闭
+)
+
+func (s State) String() string {
+	switch s {
+	case StateInit:
+		return "Init"
+	case StateWaitingLogin:
+		return "WaitingLogin"
+	case StateLoggedIn:
+		return "LoggedIn"
+	case StateLoggedOut:
+		return "LoggedOut"
+	case StateClosing:
+		return "Closing"
+	case StateClosed:
+		return "Closed"
+	}
+	return "Unknown"
+}
+
+// LoginPolicy 登录检测策略
+type LoginPolicy struct {
+	Interval      time.Duration // 未登录时首次轮询 /api/checkLogin 的间隔
+	MaxInterval   time.Duration // 退避的最大间隔
+	Multiplier    float64       // 每次未登录后间隔的增长倍数
+	WatchInterval time.Duration // 登录后检测掉线、换号的间隔
+	MaxFailures   int           // 连续多少次无法请求 wxhelper（ErrRequest）后视为掉线，<=1 表示一次即视为掉线
+}
+
+var DefaultLoginPolicy = LoginPolicy{
+	Interval:      time.Second,
+	MaxInterval:   30 * time.Second,
+	Multiplier:    2,
+	WatchInterval: 10 * time.Second,
+	MaxFailures:   3,
+}
+
+// next 下一次轮询间隔
+func (p LoginPolicy) next(cur time.Duration) time.Duration {
+	next := time.Duration(float64(cur) * p.Multiplier)
+	if next < p.Interval {
+		next = p.Interval
+	}
+	if p.MaxInterval > 0 && next > p.MaxInterval {
+		next = p.MaxInterval
+	}
+	return next
+}
+
+// loginHooks 登录、登出回调
+type loginHooks struct {
+	mu       sync.RWMutex
+	onLogin  []func(account *Account)
+	onLogout []func(account *Account)
+}
+
+// State 当前登录状态
+func (c *Client) State() State {
+	return State(c.state.Load())
+}
+
+func (c *Client) setState(s State) State {
+	return State(c.state.Swap(int32(s)))
+}
+
+// closing 正在关闭或已关闭
+func (s State) closing() bool {
+	return s == StateClosing || s == StateClosed
+}
+
+// transition 非关闭状态下迁移到 s，客户端正在关闭或已关闭时返回 false
+func (c *Client) transition(s State) bool {
+	for {
+		cur := c.State()
+		if cur.closing() {
+			return false
+		}
+		if c.state.CompareAndSwap(int32(cur), int32(s)) {
+			return true
+		}
+	}
+}
+
+// SetLoginPolicy 设置登录检测策略，需在 Run 之前调用
+func (c *Client) SetLoginPolicy(policy LoginPolicy) {
+	c.loginPolicy = policy
+}
+
+// OnLogin 注册登录成功回调（包括掉线后重新登录、切换账号）
+func (c *Client) OnLogin(fn func(account *Account)) {
+	c.hooks.mu.Lock()
+	defer c.hooks.mu.Unlock()
+	c.hooks.onLogin = append(c.hooks.onLogin, fn)
+}
+
+// OnLogout 注册掉线回调，参数为掉线前的账号
+func (c *Client) OnLogout(fn func(account *Account)) {
+	c.hooks.mu.Lock()
+	defer c.hooks.mu.Unlock()
+	c.hooks.onLogout = append(c.hooks.onLogout, fn)
+}
+
+// WaitLogin 阻塞直到登录成功或 ctx 结束
+func (c *Client) WaitLogin(ctx context.Context) error {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		switch c.State() {
+		case StateLoggedIn:
+			return nil
+		case StateClosing, StateClosed:
+			return ErrClientClosed
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.ctx.Done():
+			return ErrClientClosed
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkLogin 检测一次登录状态并完成状态迁移，返回是否已登录
+// 仅 wxhelper 明确返回未登录，或连续 MaxFailures 次无法请求 wxhelper 时视为掉线，其余错误保持当前状态
+func (c *Client) checkLogin(ctx context.Context) bool {
+	ok, err := c.wxClient.CheckLogin(ctx)
+	if err != nil {
+		failures := int(c.loginFailures.Add(1))
+		c.log.WarnWithErr(err, "checkLogin error", map[string]interface{}{"failures": failures})
+		if errors.Is(err, ErrRequest) && failures >= c.loginPolicy.MaxFailures {
+			c.handleLogout()
+		}
+		return c.State() == StateLoggedIn
+	}
+	c.loginFailures.Store(0)
+	if !ok {
+		c.handleLogout()
+		return false
+	}
+	if c.State() != StateLoggedIn {
+		return c.handleLogin(ctx)
+	}
+	// 已登录：检测是否切换了账号
+	old := c.Self()
+	account, err := c.refreshAccount(ctx)
+	if err != nil {
+		c.log.WarnWithErr(err, "refresh account error")
+		return true
+	}
+	if old != nil && old.Wxid != account.Wxid {
+		c.log.Warn("account switched", map[string]interface{}{"from": old.Wxid, "to": account.Wxid})
+		c.fireLogout(old)
+		if !c.transition(StateWaitingLogin) {
+			return false
+		}
+		return c.handleLogin(ctx)
+	}
+	return true
+}
+
+// handleLogin 登录成功：获取账号信息、重新注册消息 hook 并回调
+func (c *Client) handleLogin(ctx context.Context) bool {
+	account, err := c.refreshAccount(ctx)
+	if err != nil {
+		c.log.ErrorWithErr(err, "refresh account error")
+		return false
+	}
+	// 微信重启后 hook 会失效，每次登录都重新注册
+	if registrar, ok := c.listener.(HookRegistrar); ok {
+		if err = registrar.RegisterHook(ctx, c.wxClient); err != nil {
+			c.log.ErrorWithErr(err, "hook sync msg error")
+			return false
+		}
+	}
+	if !c.transition(StateLoggedIn) {
+		return false
+	}
+	c.log.Info("login success", map[string]interface{}{"account": account})
+	c.contacts.notifyRefresh() // 登录或换号后重新加载联系人
+	c.chatRooms.clear()
+	c.hooks.mu.RLock()
+	fns := c.hooks.onLogin // 只追加不修改，复制切片头即可在释放锁后回调，回调中可再注册
+	c.hooks.mu.RUnlock()
+	for _, fn := range fns {
+		fn(account)
+	}
+	return true
+}
+
+// handleLogout 未登录或掉线
+func (c *Client) handleLogout() {
+	if !c.state.CompareAndSwap(int32(StateLoggedIn), int32(StateLoggedOut)) {
+		c.state.CompareAndSwap(int32(StateInit), int32(StateWaitingLogin))
+		return
+	}
+	account := c.Self()
+	c.log.Warn("logged out, waiting for re-login", map[string]interface{}{"account": account})
+	c.fireLogout(account)
+}
+
+func (c *Client) fireLogout(account *Account) {
+	c.hooks.mu.RLock()
+	fns := c.hooks.onLogout
+	c.hooks.mu.RUnlock()
+	for _, fn := range fns {
+		fn(account)
+	}
+}
+
+// watchLogin 持续检测登录状态：未登录时按退避间隔轮询，登录后定期检测掉线与换号
+func (c *Client) watchLogin() {
+	policy := c.loginPolicy
+	delay := policy.Interval
+	for {
+		wait := policy.WatchInterval
+		if c.State() != StateLoggedIn {
+			wait = delay
+			delay = policy.next(delay)
+		}
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		if c.checkLogin(c.ctx) {
+			delay = policy.Interval
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package wxhelper_sdk
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeWxHelper 模拟 wxhelper 的登录相关接口
+type fakeWxHelper struct {
+	mu     sync.Mutex
+	login  bool
+	wxid   string
+	status int // 非 0 时 checkLogin 返回该状态码，-1 表示断开连接
+	hooked atomic.Int32
+}
+
+func (f *fakeWxHelper) set(login bool, wxid string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.login, f.wxid = login, wxid
+}
+
+func (f *fakeWxHelper) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	login, wxid, status := f.login, f.wxid, f.status
+	f.mu.Unlock()
+	resp := map[string]any{"code": 0, "msg": "success"}
+	switch r.URL.Path {
+	case "/api/checkLogin":
+		if status < 0 {
+			panic(http.ErrAbortHandler)
+		}
+		if status > 0 {
+			w.WriteHeader(status)
+			return
+		}
+		if login {
+			resp["code"] = 1
+		}
+	case "/api/userInfo":
+		resp["code"] = 1
+		resp["data"] = map[string]any{"wxid": wxid, "name": wxid, "dbKey": "secret"}
+	case "/api/hookSyncMsg":
+		f.hooked.Add(1)
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func TestClient_LoginStateMachine(t *testing.T) {
+	fake := &fakeWxHelper{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := NewClient(WithAPIBaseURL(server.URL), WithBufferSize(10))
+	defer client.stop()
+	var logins, logouts []string
+	client.OnLogin(func(account *Account) { logins = append(logins, account.Wxid) })
+	client.OnLogout(func(account *Account) { logouts = append(logouts, account.Wxid) })
+	client.state.Store(int32(StateWaitingLogin))
+
+	// 未登录
+	assert.False(t, client.checkLogin(client.ctx))
+	assert.Equal(t, StateWaitingLogin, client.State())
+	assert.Nil(t, client.Self())
+
+	// 登录
+	fake.set(true, "wxid_a")
+	assert.True(t, client.checkLogin(client.ctx))
+	assert.Equal(t, StateLoggedIn, client.State())
+	assert.Equal(t, "wxid_a", client.Self().Wxid)
+	assert.Equal(t, int32(1), fake.hooked.Load())
+
+	// 切换账号
+	fake.set(true, "wxid_b")
+	assert.True(t, client.checkLogin(client.ctx))
+	assert.Equal(t, "wxid_b", client.Self().Wxid)
+	assert.Equal(t, int32(2), fake.hooked.Load())
+
+	// 微信退出后重新登录，需重新 hook
+	fake.set(false, "wxid_b")
+	assert.False(t, client.checkLogin(client.ctx))
+	assert.Equal(t, StateLoggedOut, client.State())
+	fake.set(true, "wxid_b")
+	assert.True(t, client.checkLogin(client.ctx))
+	assert.Equal(t, int32(3), fake.hooked.Load())
+
+	assert.Equal(t, []string{"wxid_a", "wxid_b", "wxid_b"}, logins)
+	assert.Equal(t, []string{"wxid_a", "wxid_b"}, logouts)
+}
+
+func TestClient_CheckLoginFailures(t *testing.T) {
+	fake := &fakeWxHelper{login: true, wxid: "wxid_a"}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := NewClient(WithAPIBaseURL(server.URL), WithBufferSize(10), WithRetryPolicy(RetryPolicy{}))
+	defer client.stop()
+	var logouts int
+	client.OnLogout(func(account *Account) { logouts++ })
+	client.state.Store(int32(StateWaitingLogin))
+	assert.True(t, client.checkLogin(client.ctx))
+
+	// 非网络错误不视为掉线
+	fake.mu.Lock()
+	fake.status = http.StatusInternalServerError
+	fake.mu.Unlock()
+	for i := 0; i < DefaultLoginPolicy.MaxFailures+1; i++ {
+		assert.True(t, client.checkLogin(client.ctx))
+	}
+	assert.Equal(t, StateLoggedIn, client.State())
+
+	// 连续 MaxFailures 次无法请求后视为掉线
+	fake.mu.Lock()
+	fake.status = -1
+	fake.mu.Unlock()
+	client.loginFailures.Store(0)
+	for i := 1; i < DefaultLoginPolicy.MaxFailures; i++ {
+		assert.True(t, client.checkLogin(client.ctx))
+	}
+	assert.Equal(t, 0, logouts)
+	assert.False(t, client.checkLogin(client.ctx))
+	assert.Equal(t, StateLoggedOut, client.State())
+	assert.Equal(t, 1, logouts)
+
+	// 恢复后重新登录，失败计数清零
+	fake.mu.Lock()
+	fake.status = 0
+	fake.mu.Unlock()
+	assert.True(t, client.checkLogin(client.ctx))
+	assert.Equal(t, int32(0), client.loginFailures.Load())
+}
+
+func TestClient_HooksReentrant(t *testing.T) {
+	fake := &fakeWxHelper{login: true, wxid: "wxid_a"}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := NewClient(WithAPIBaseURL(server.URL), WithBufferSize(10))
+	defer client.stop()
+	var logins int
+	// 回调中注册新回调不会死锁
+	client.OnLogin(func(account *Account) {
+		client.OnLogin(func(account *Account) { logins++ })
+	})
+	client.state.Store(int32(StateWaitingLogin))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.True(t, client.checkLogin(client.ctx))
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("login hook deadlocked")
+	}
+	assert.Equal(t, 0, logins)
+}
+
+// hookingListener 自定义监听者，通过 HookRegistrar 在登录后注册 hook
+type hookingListener struct {
+	*ChanMessageListener
+	registered int
+}
+
+func (l *hookingListener) RegisterHook(ctx context.Context, api HookAPI) error {
+	l.registered++
+	return api.HookSyncMsg(ctx)
+}
+
+func TestClient_CustomHookRegistrar(t *testing.T) {
+	fake := &fakeWxHelper{login: true, wxid: "wxid_a"}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	listener := &hookingListener{ChanMessageListener: NewChanMessageListener(1)}
+	client := NewClient(WithAPIBaseURL(server.URL), WithListener(listener))
+	defer client.stop()
+	client.state.Store(int32(StateWaitingLogin))
+	assert.True(t, client.checkLogin(client.ctx))
+	assert.Equal(t, 1, listener.registered)
+	assert.Equal(t, int32(1), fake.hooked.Load())
+}
+
+func TestLoginPolicy_Next(t *testing.T) {
+	p := DefaultLoginPolicy
+	assert.Equal(t, p.Interval*2, p.next(p.Interval))
+	assert.Equal(t, p.MaxInterval, p.next(p.MaxInterval))
+}
@@ -6,9 +6,15 @@ package wxhelper_sdk
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+	"wxhelper-sdk/inner"
 	"wxhelper-sdk/logging"
 )
 
@@ -24,65 +30,299 @@ func (f MessageHandlerFunc) HandleMessage(message *Message) error {
 	return f(message)
 }
 
-// ReaderMessageHandler 从reader中解析 Message 并处理
+// ReaderMessageHandler 从reader中解析 Message 并处理，同一个 reader 中可连续读取多条消息
 type ReaderMessageHandler struct {
 	Reader         io.Reader
 	MessageHandler MessageHandler
+	Framing        Framing // 消息分帧方式，默认 FramingJSON
+	MaxPayloadSize int64   // 单条消息的最大字节数，<=0 不限制
+
+	framer framer
+	log    *logging.FieldLogger
 }
 
-func (rmh *ReaderMessageHandler) Serve() error {
+// Next 读取下一条消息，reader 中没有更多消息时返回 io.EOF
+func (rmh *ReaderMessageHandler) Next() (*Message, error) {
+	if rmh.framer == nil {
+		rmh.framer = newFramer(rmh.Framing, rmh.Reader, rmh.MaxPayloadSize)
+	}
 	var msg Message
-	if err := json.NewDecoder(rmh.Reader).Decode(&msg); err != nil {
+	if err := rmh.framer.next(&msg); err != nil {
+		return nil, err
+	}
+	if rmh.log == nil {
+		rmh.log = logging.Default()
+	}
+	rmh.log.Debug("parse message successfully", map[string]interface{}{"msgId": msg.MsgId, "type": msg.Type})
+	return &msg, nil
+}
+
+// Serve 读取并处理一条消息
+func (rmh *ReaderMessageHandler) Serve() error {
+	msg, err := rmh.Next()
+	if err != nil {
 		return err
 	}
-	logging.Info("parse message successfully")
-	logging.Debug("[MessageHandler]", map[string]interface{}{"msg": msg})
-	err := rmh.MessageHandler.HandleMessage(&msg)
+	err = rmh.MessageHandler.HandleMessage(msg)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-// MessageListener 消息监听者
+// MessageListener 消息监听者，Client 通过它接收消息，可替换为任意实现
 type MessageListener interface {
-	ListenAndServe(handler MessageHandler) error
+	// ListenAndServe 接收消息并交给 messageHandler 处理，阻塞直到 ctx 结束或 Shutdown
+	ListenAndServe(ctx context.Context, messageHandler MessageHandler) error
+	// Shutdown 立即停止接收消息，并等待正在处理的消息完成或 ctx 结束
+	Shutdown(ctx context.Context) error
+}
+
+// HookAPI 向 wxhelper 注册消息 hook 的接口，由 Client 传给 HookRegistrar
+type HookAPI interface {
+	// HookSyncMsg wxhelper 以 tcp 方式推送消息到 WithHookURL 配置的地址
+	HookSyncMsg(ctx context.Context) error
+	// HTTPHookSyncMsg wxhelper 以 http 方式推送消息到 callbackURL
+	HTTPHookSyncMsg(ctx context.Context, callbackURL *url.URL, timeout time.Duration) error
+}
+
+// HookRegistrar 需要向 wxhelper 注册 hook 的监听者（如 tcp、http），每次登录后由 Client 调用，关闭时 Client 取消 hook
+// 自定义监听者实现该接口即可接收 wxhelper 推送的消息
+type HookRegistrar interface {
+	RegisterHook(ctx context.Context, api HookAPI) error
+}
+
+// loggerSetter 由 Client 注入日志的监听者
+type loggerSetter interface {
+	setLogger(log *logging.FieldLogger)
+}
+
+// listenerLog 内置监听者使用的日志，未注入时使用全局日志
+type listenerLog struct {
+	log *logging.FieldLogger
+}
+
+func (l *listenerLog) setLogger(log *logging.FieldLogger) {
+	l.log = log
 }
 
-// TCPMessageListener tcp实现
+func (l *listenerLog) logger() *logging.FieldLogger {
+	if l.log == nil {
+		return logging.Default()
+	}
+	return l.log
+}
+
+var (
+	_ HookAPI         = (*inner.WxClient)(nil)
+	_ HookRegistrar   = (*TCPMessageListener)(nil)
+	_ HookRegistrar   = (*HTTPMessageListener)(nil)
+	_ MessageListener = (*TCPMessageListener)(nil)
+	_ MessageListener = (*HTTPMessageListener)(nil)
+	_ MessageListener = (*ChanMessageListener)(nil)
+	_ MessageListener = (*ReplayMessageListener)(nil)
+)
+
+var (
+	ErrListenerClosed    = errors.New("listener closed")
+	ErrInvalidHookConfig = errors.New("invalid hook config")
+)
+
+// invalidListener hook 配置有误时使用，监听与注册 hook 均返回配置错误，避免静默回退为其他接收方式
+type invalidListener struct {
+	err error
+}
+
+func (l *invalidListener) ListenAndServe(ctx context.Context, messageHandler MessageHandler) error {
+	return l.err
+}
+
+func (l *invalidListener) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+func (l *invalidListener) RegisterHook(ctx context.Context, api HookAPI) error {
+	return l.err
+}
+
+// TCPMessageListener tcp实现，支持单个连接上连续发送多条消息
 type TCPMessageListener struct {
-	Addr string
+	Addr           string
+	Framing        Framing       // 消息分帧方式，默认 FramingJSON
+	ReadTimeout    time.Duration // 读取单条消息（及连接空闲）的超时时间
+	MaxPayloadSize int64         // 单条消息的最大字节数，base64 图片可能很大
+
+	listenerLog
+	mu       sync.Mutex
+	listener net.Listener
+	closed   bool
+	conns    map[net.Conn]bool // 正在处理的连接，值为是否空闲（等待下一条消息）
+	inFlight sync.WaitGroup    // 正在处理的连接
 }
 
-// ListenAndServe 启动tcp服务并监听处理消息
+// ListenAndServe 启动tcp服务并监听处理消息，ctx 结束或 Close 后返回 ErrListenerClosed
 func (tl *TCPMessageListener) ListenAndServe(ctx context.Context, messageHandler MessageHandler) error {
 	listener, err := net.Listen("tcp", tl.Addr)
 	if err != nil {
 		return err
 	}
-	defer func() { _ = listener.Close() }()
+	tl.mu.Lock()
+	if tl.closed {
+		tl.mu.Unlock()
+		_ = listener.Close()
+		return ErrListenerClosed
+	}
+	tl.listener = listener
+	tl.mu.Unlock()
+	defer func() { _ = tl.Close() }()
+
+	stop := context.AfterFunc(ctx, func() { _ = tl.Close() }) // ctx 结束时立即中断 Accept
+	defer stop()
 	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
 		conn, err := listener.Accept()
 		if err != nil {
+			if tl.isClosed() {
+				return ErrListenerClosed
+			}
 			return err
 		}
+		if !tl.track(conn) { // Accept 与 Close 竞争时，Shutdown 已开始等待，不再处理新连接
+			_ = conn.Close()
+			return ErrListenerClosed
+		}
 		go tl.processMessage(conn, messageHandler) // 处理每个接收到的消息
 	}
 }
 
+// track 未关闭时登记一个正在处理的连接，与 Close 在同一把锁下进行，保证 Shutdown 等待到全部连接
+func (tl *TCPMessageListener) track(conn net.Conn) bool {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+	if tl.closed {
+		return false
+	}
+	if tl.conns == nil {
+		tl.conns = make(map[net.Conn]bool)
+	}
+	tl.conns[conn] = false
+	tl.inFlight.Add(1)
+	return true
+}
+
+func (tl *TCPMessageListener) untrack(conn net.Conn) {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+	delete(tl.conns, conn)
+}
+
+// idle 标记连接已处理完上一条消息、开始等待下一条，已关闭时返回 false
+func (tl *TCPMessageListener) idle(conn net.Conn) bool {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+	if tl.closed {
+		return false
+	}
+	tl.conns[conn] = true
+	return true
+}
+
+func (tl *TCPMessageListener) busy(conn net.Conn) {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+	tl.conns[conn] = false
+}
+
+func (tl *TCPMessageListener) isClosed() bool {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+	return tl.closed
+}
+
+// Close 立即停止接收新连接，并中断空闲（等待下一条消息）的持久连接
+func (tl *TCPMessageListener) Close() error {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+	if tl.closed {
+		return nil
+	}
+	tl.closed = true
+	for conn, idle := range tl.conns {
+		if idle {
+			_ = conn.SetReadDeadline(time.Now()) // 读取立即超时，连接随之关闭
+		}
+	}
+	if tl.listener == nil {
+		return nil
+	}
+	return tl.listener.Close()
+}
+
+// Shutdown 停止接收新连接，并等待正在处理的消息完成或 ctx 结束，空闲的持久连接直接关闭
+func (tl *TCPMessageListener) Shutdown(ctx context.Context) error {
+	err := tl.Close()
+	if waitErr := waitGroupWithContext(ctx, &tl.inFlight); waitErr != nil {
+		return errors.Join(err, fmt.Errorf("wait in-flight messages: %w", waitErr))
+	}
+	return err
+}
+
+// RegisterHook 注册 tcp 方式的消息 hook
+func (tl *TCPMessageListener) RegisterHook(ctx context.Context, api HookAPI) error {
+	return api.HookSyncMsg(ctx)
+}
+
 // 处理每个tcp连接消息
+// 每条消息处理后回复 ack：成功 200，解析失败 400（随后关闭连接），处理失败 500，格式见 Framing
 func (tl *TCPMessageListener) processMessage(conn net.Conn, messageHandler MessageHandler) {
+	defer tl.inFlight.Done()
+	defer tl.untrack(conn)
 	defer func() { _ = conn.Close() }()
-	defer func() { _, _ = conn.Write([]byte("200 OK")) }()
-	handler := ReaderMessageHandler{Reader: conn, MessageHandler: messageHandler}
-	_ = handler.Serve()
+	log := tl.logger()
+	reader := ReaderMessageHandler{Reader: conn, MessageHandler: messageHandler, Framing: tl.Framing, MaxPayloadSize: tl.MaxPayloadSize, log: log}
+	for handled := 0; ; handled++ {
+		if tl.ReadTimeout > 0 {
+			_ = conn.SetReadDeadline(time.Now().Add(tl.ReadTimeout))
+		}
+		// 第一条消息之前不视为空闲，wxhelper 建立连接后即发送消息，关闭时等待其处理完成
+		if handled > 0 && !tl.idle(conn) {
+			return
+		}
+		msg, err := reader.Next()
+		tl.busy(conn)
+		if err != nil {
+			if errors.Is(err, io.EOF) || (handled > 0 && errors.Is(err, os.ErrDeadlineExceeded)) {
+				return // 对端关闭或空闲超时
+			}
+			log.WarnWithErr(err, "read tcp message failed", map[string]interface{}{"remote": conn.RemoteAddr().String()})
+			_ = tl.ack(conn, ackBadRequest)
+			return
+		}
+		status := ackOK
+		if err = messageHandler.HandleMessage(msg); err != nil {
+			log.ErrorWithErr(err, "handle tcp message failed", map[string]interface{}{"msgId": msg.MsgId})
+			status = ackInternalError
+		}
+		if err = tl.ack(conn, status); err != nil {
+			return
+		}
+	}
+}
+
+func (tl *TCPMessageListener) ack(conn net.Conn, status string) error {
+	if tl.ReadTimeout > 0 {
+		_ = conn.SetWriteDeadline(time.Now().Add(tl.ReadTimeout))
+	}
+	if tl.Framing == FramingLengthPrefixed {
+		status += "\n"
+	}
+	_, err := conn.Write([]byte(status))
+	return err
 }
 
 func NewTCPMessageListener(addr string) *TCPMessageListener {
-	return &TCPMessageListener{Addr: ":" + addr}
+	return &TCPMessageListener{
+		Addr:           ":" + addr,
+		ReadTimeout:    DefaultReadTimeout,
+		MaxPayloadSize: DefaultMaxPayloadSize,
+	}
 }
@@ -0,0 +1,150 @@
+// Package wxhelper_sdk
+// @Author Clover
+// @Data 2025/1/15 上午10:40:00
+// @Desc HTTP-Hook 消息接收
+package wxhelper_sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultHTTPHookTimeout = 30 * time.Second
+	defaultMaxBodySize     = 32 << 20 // base64 图片可能很大
+)
+
+// HTTPMessageListener 以 HTTP 回调的方式接收 wxhelper 推送的消息
+// 既可以通过 ListenAndServe 独立运行，也可以作为 http.Handler 挂载到已有的服务中（此时 Addr 置空）
+type HTTPMessageListener struct {
+	Addr        string        // 独立运行时的监听地址，如 ":19099"；为空表示挂载模式
+	CallbackURL *url.URL      // wxhelper 推送消息的地址，需从 wxhelper 所在机器可访问（可为反向代理地址）
+	Timeout     time.Duration // wxhelper 推送消息的超时时间
+
+	listenerLog
+	mu       sync.RWMutex
+	handler  MessageHandler
+	server   *http.Server
+	done     chan struct{}
+	closed   bool
+	inFlight sync.WaitGroup
+}
+
+// NewHTTPMessageListener 新建 HTTP 消息监听 <addr: 监听地址，挂载模式传空>, <callbackURL: wxhelper 回调地址>
+func NewHTTPMessageListener(addr string, callbackURL string, timeout time.Duration) (*HTTPMessageListener, error) {
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse callback url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("callback url must be http(s): %s", callbackURL)
+	}
+	if timeout <= 0 {
+		timeout = DefaultHTTPHookTimeout
+	}
+	return &HTTPMessageListener{Addr: addr, CallbackURL: u, Timeout: timeout, done: make(chan struct{})}, nil
+}
+
+// ServeHTTP 实现 http.Handler，解析 wxhelper 推送的消息并交给 handler 处理
+func (hl *HTTPMessageListener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	hl.mu.RLock()
+	handler, closed := hl.handler, hl.closed
+	if handler != nil && !closed {
+		hl.inFlight.Add(1)
+	}
+	hl.mu.RUnlock()
+	if handler == nil || closed {
+		http.Error(w, "listener not serving", http.StatusServiceUnavailable)
+		return
+	}
+	defer hl.inFlight.Done()
+
+	var msg Message
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, defaultMaxBodySize)).Decode(&msg); err != nil {
+		hl.logger().WarnWithErr(err, "decode http hook message failed")
+		writeHookResult(w, http.StatusBadRequest, err)
+		return
+	}
+	hl.logger().Debug("parse message successfully", map[string]interface{}{"msgId": msg.MsgId, "type": msg.Type})
+	if err := handler.HandleMessage(&msg); err != nil {
+		hl.logger().ErrorWithErr(err, "handle http hook message failed", map[string]interface{}{"msgId": msg.MsgId})
+		writeHookResult(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeHookResult(w, http.StatusOK, nil)
+}
+
+func writeHookResult(w http.ResponseWriter, status int, err error) {
+	resp := map[string]interface{}{"code": 0, "msg": "success"}
+	if err != nil {
+		resp["code"], resp["msg"] = -1, err.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// ListenAndServe 开始接收消息；挂载模式下仅注册 handler 并阻塞至 ctx 结束或 Close
+func (hl *HTTPMessageListener) ListenAndServe(ctx context.Context, messageHandler MessageHandler) error {
+	hl.mu.Lock()
+	if hl.closed {
+		hl.mu.Unlock()
+		return ErrListenerClosed
+	}
+	hl.handler = messageHandler
+	if hl.Addr != "" {
+		hl.server = &http.Server{Addr: hl.Addr, Handler: hl, ReadHeaderTimeout: 10 * time.Second}
+	}
+	server := hl.server
+	hl.mu.Unlock()
+
+	stop := context.AfterFunc(ctx, func() { _ = hl.Close() })
+	defer stop()
+	if server == nil {
+		<-hl.done
+		return ErrListenerClosed
+	}
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return ErrListenerClosed
+}
+
+// Close 立即停止接收消息
+func (hl *HTTPMessageListener) Close() error {
+	hl.mu.Lock()
+	defer hl.mu.Unlock()
+	if hl.closed {
+		return nil
+	}
+	hl.closed = true
+	close(hl.done)
+	if hl.server != nil {
+		return hl.server.Close()
+	}
+	return nil
+}
+
+// Shutdown 停止接收消息，并等待正在处理的消息完成或 ctx 结束
+func (hl *HTTPMessageListener) Shutdown(ctx context.Context) error {
+	err := hl.Close()
+	if waitErr := waitGroupWithContext(ctx, &hl.inFlight); waitErr != nil {
+		return errors.Join(err, fmt.Errorf("wait in-flight messages: %w", waitErr))
+	}
+	return err
+}
+
+// RegisterHook 注册 http 方式的消息 hook，wxhelper 将消息推送到 CallbackURL
+func (hl *HTTPMessageListener) RegisterHook(ctx context.Context, api HookAPI) error {
+	return api.HTTPHookSyncMsg(ctx, hl.CallbackURL, hl.Timeout)
+}
@@ -0,0 +1,113 @@
+package wxhelper_sdk
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"wxhelper-sdk/logging"
+)
+
+func TestHTTPMessageListener_Mounted(t *testing.T) {
+	listener, err := NewHTTPMessageListener("", "http://bot.example.com/wx/hook", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultHTTPHookTimeout, listener.Timeout)
+
+	mux := http.NewServeMux()
+	mux.Handle("/wx/hook", listener)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// 未开始监听时拒绝
+	resp, err := http.Post(server.URL+"/wx/hook", "application/json", strings.NewReader(`{}`))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	_ = resp.Body.Close()
+
+	received := make(chan *Message, 1)
+	served := make(chan error, 1)
+	go func() {
+		served <- listener.ListenAndServe(context.Background(), MessageHandlerFunc(func(message *Message) error {
+			if message.Content == "fail" {
+				return errors.New("handler failed")
+			}
+			received <- message
+			return nil
+		}))
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	resp, err = http.Post(server.URL+"/wx/hook", "application/json", strings.NewReader(`{"msgId":42,"type":1,"content":"hi"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_ = resp.Body.Close()
+	msg := <-received
+	assert.Equal(t, int64(42), msg.MsgId)
+
+	resp, err = http.Post(server.URL+"/wx/hook", "application/json", strings.NewReader(`{"msgId":`))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	_ = resp.Body.Close()
+
+	resp, err = http.Post(server.URL+"/wx/hook", "application/json", strings.NewReader(`{"content":"fail"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	_ = resp.Body.Close()
+
+	assert.Nil(t, listener.Shutdown(context.Background()))
+	assert.Equal(t, ErrListenerClosed, <-served)
+}
+
+func TestNewHTTPMessageListener_InvalidURL(t *testing.T) {
+	_, err := NewHTTPMessageListener("", "127.0.0.1:19099", time.Second)
+	assert.NotNil(t, err)
+}
+
+func TestClientOptions_NewListener(t *testing.T) {
+	t.Setenv(ENVHookMode, HookModeHTTP)
+	t.Setenv(ENVHttpHookURL, "http://10.0.0.2:8080/hook")
+	o := optionsFromEnv()
+	o.listenAddr = "19099"
+	listener, ok := o.newListener(logging.Default()).(*HTTPMessageListener)
+	if assert.True(t, ok) {
+		assert.Equal(t, ":19099", listener.Addr)
+		assert.Equal(t, "10.0.0.2", listener.CallbackURL.Hostname())
+	}
+
+	// NewClient 未指定 Option 时按环境变量选择监听方式
+	client := NewClient(WithListenAddr("19099"))
+	listener, ok = client.listener.(*HTTPMessageListener)
+	if assert.True(t, ok) {
+		assert.Equal(t, "10.0.0.2", listener.CallbackURL.Hostname())
+	}
+
+	// http 配置有误时不回退为 tcp，监听与注册 hook 返回配置错误
+	t.Setenv(ENVHttpHookURL, "10.0.0.2:8080")
+	invalid := NewClient(WithListenAddr("19099"), WithCacheDir(t.TempDir()))
+	defer invalid.stop()
+	_, ok = invalid.listener.(*TCPMessageListener)
+	assert.False(t, ok)
+	assert.ErrorIs(t, invalid.startListen(), ErrInvalidHookConfig)
+	registrar, ok := invalid.listener.(HookRegistrar)
+	if assert.True(t, ok) {
+		assert.ErrorIs(t, registrar.RegisterHook(context.Background(), invalid.wxClient), ErrInvalidHookConfig)
+	}
+
+	// Option 优先于环境变量
+	client = NewClient(WithListenAddr("19099"), WithHTTPHook("http://10.0.0.3:8080/hook", time.Second))
+	listener, ok = client.listener.(*HTTPMessageListener)
+	if assert.True(t, ok) {
+		assert.Equal(t, "10.0.0.3", listener.CallbackURL.Hostname())
+		assert.Equal(t, time.Second, listener.Timeout)
+		assert.Same(t, client.log, listener.logger()) // 监听者使用客户端的日志
+	}
+
+	t.Setenv(ENVHookMode, HookModeTCP)
+	o = optionsFromEnv()
+	_, ok = o.newListener(logging.Default()).(*TCPMessageListener)
+	assert.True(t, ok)
+}
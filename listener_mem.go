@@ -0,0 +1,146 @@
+// Package wxhelper_sdk
+// @Author Clover
+// @Data 2025/1/15 下午3:30:00
+// @Desc 内存通道与 jsonl 回放的消息监听，便于测试及离线调试
+package wxhelper_sdk
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// ChanMessageListener 基于内存通道的监听者，通过 Send 注入消息
+type ChanMessageListener struct {
+	listenerLog
+	msgCH     chan *Message
+	done      chan struct{}
+	closeOnce sync.Once
+	inFlight  sync.WaitGroup
+}
+
+// NewChanMessageListener 新建内存监听者 <size: 通道缓冲大小>
+func NewChanMessageListener(size int) *ChanMessageListener {
+	return &ChanMessageListener{
+		msgCH: make(chan *Message, size),
+		done:  make(chan struct{}),
+	}
+}
+
+// Send 注入一条消息，通道已满时阻塞
+func (cl *ChanMessageListener) Send(ctx context.Context, msg *Message) error {
+	select {
+	case <-cl.done:
+		return ErrListenerClosed
+	default:
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-cl.done:
+		return ErrListenerClosed
+	case cl.msgCH <- msg:
+		return nil
+	}
+}
+
+// ListenAndServe 依次处理注入的消息
+func (cl *ChanMessageListener) ListenAndServe(ctx context.Context, messageHandler MessageHandler) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ErrListenerClosed
+		case <-cl.done:
+			return ErrListenerClosed
+		case msg := <-cl.msgCH:
+			cl.inFlight.Add(1)
+			if err := messageHandler.HandleMessage(msg); err != nil {
+				cl.logger().ErrorWithErr(err, "handle message failed", map[string]interface{}{"msgId": msg.MsgId})
+			}
+			cl.inFlight.Done()
+		}
+	}
+}
+
+// Shutdown 停止处理消息
+func (cl *ChanMessageListener) Shutdown(ctx context.Context) error {
+	cl.closeOnce.Do(func() { close(cl.done) })
+	return waitGroupWithContext(ctx, &cl.inFlight)
+}
+
+// ReplayMessageListener 从 jsonl 文件（每行一条 Message，如 Close 持久化的未消费消息）回放消息
+type ReplayMessageListener struct {
+	Path     string
+	Interval time.Duration // 每条消息之间的间隔
+
+	listenerLog
+	done      chan struct{}
+	closeOnce sync.Once
+	inFlight  sync.WaitGroup
+}
+
+// NewReplayMessageListener 新建回放监听者 <path: jsonl 文件路径>
+func NewReplayMessageListener(path string, interval time.Duration) *ReplayMessageListener {
+	return &ReplayMessageListener{Path: path, Interval: interval, done: make(chan struct{})}
+}
+
+// ListenAndServe 回放文件中的全部消息，回放完成后返回 nil
+func (rl *ReplayMessageListener) ListenAndServe(ctx context.Context, messageHandler MessageHandler) error {
+	file, err := os.Open(rl.Path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+	return rl.replay(ctx, file, messageHandler)
+}
+
+func (rl *ReplayMessageListener) replay(ctx context.Context, reader io.Reader, messageHandler MessageHandler) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), defaultMaxBodySize)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var msg Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			return fmt.Errorf("replay line %d: %w", line, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ErrListenerClosed
+		case <-rl.done:
+			return ErrListenerClosed
+		default:
+		}
+		rl.inFlight.Add(1)
+		if err := messageHandler.HandleMessage(&msg); err != nil {
+			rl.logger().ErrorWithErr(err, "handle replay message failed", map[string]interface{}{"line": line})
+		}
+		rl.inFlight.Done()
+		if rl.Interval > 0 {
+			select {
+			case <-ctx.Done():
+				return ErrListenerClosed
+			case <-rl.done:
+				return ErrListenerClosed
+			case <-time.After(rl.Interval):
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown 停止回放
+func (rl *ReplayMessageListener) Shutdown(ctx context.Context) error {
+	rl.closeOnce.Do(func() { close(rl.done) })
+	return waitGroupWithContext(ctx, &rl.inFlight)
+}
@@ -0,0 +1,52 @@
+package wxhelper_sdk
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestChanMessageListener_Client(t *testing.T) {
+	listener := NewChanMessageListener(4)
+	client := NewClient(WithListener(listener), WithBufferSize(4))
+	client.state.Store(int32(StateLoggedIn))
+	go func() { _ = client.startListen() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	assert.Nil(t, listener.Send(ctx, &Message{MsgId: 7, Type: MsgTypeText, FromUser: "wxid_a", Content: "hi"}))
+	msg, err := client.GetMsg()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), msg.MsgId)
+	assert.Equal(t, client, msg.client)
+
+	// 非 hook 监听者关闭时不请求 wxhelper
+	assert.Nil(t, client.Close(ctx))
+	assert.Equal(t, ErrListenerClosed, listener.Send(ctx, &Message{}))
+}
+
+func TestReplayMessageListener(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "messages.jsonl")
+	data := `{"msgId":1,"type":1,"content":"a"}
+{"msgId":2,"type":3,"content":"b"}
+
+{"msgId":3,"type":1,"content":"c"}
+`
+	assert.Nil(t, os.WriteFile(path, []byte(data), 0o644))
+
+	var ids []int64
+	listener := NewReplayMessageListener(path, 0)
+	err := listener.ListenAndServe(context.Background(), MessageHandlerFunc(func(message *Message) error {
+		ids = append(ids, message.MsgId)
+		return nil
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{1, 2, 3}, ids)
+
+	assert.Nil(t, os.WriteFile(path, []byte("{\"msgId\":1}\n{broken\n"), 0o644))
+	err = NewReplayMessageListener(path, 0).ListenAndServe(context.Background(), MessageHandlerFunc(func(message *Message) error { return nil }))
+	assert.ErrorContains(t, err, "replay line 2")
+}
@@ -0,0 +1,194 @@
+package wxhelper_sdk
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func lengthPrefixed(payload string) []byte {
+	buf := make([]byte, 4, 4+len(payload))
+	binary.BigEndian.PutUint32(buf, uint32(len(payload)))
+	return append(buf, payload...)
+}
+
+func TestReaderMessageHandler_Framing(t *testing.T) {
+	tests := []struct {
+		name    string
+		framing Framing
+		input   []byte
+		wantIDs []int64
+		wantErr bool
+	}{
+		{"single", FramingJSON, []byte(`{"msgId":1}`), []int64{1}, false},
+		{"newline delimited", FramingJSON, []byte("{\"msgId\":1}\n{\"msgId\":2}\n"), []int64{1, 2}, false},
+		{"concatenated", FramingJSON, []byte(`{"msgId":1}{"msgId":2}`), []int64{1, 2}, false},
+		{"truncated", FramingJSON, []byte(`{"msgId":1}{"msgId":`), []int64{1}, true},
+		{"malformed", FramingJSON, []byte(`{"msgId":1}{msgId}`), []int64{1}, true},
+		{"length prefixed", FramingLengthPrefixed, append(lengthPrefixed(`{"msgId":1}`), lengthPrefixed(`{"msgId":2}`)...), []int64{1, 2}, false},
+		{"length prefixed truncated", FramingLengthPrefixed, lengthPrefixed(`{"msgId":1}`)[:8], nil, true},
+		{"length prefixed header truncated", FramingLengthPrefixed, []byte{0, 0}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ids []int64
+			reader := ReaderMessageHandler{Reader: bytes.NewReader(tt.input), Framing: tt.framing, MaxPayloadSize: 1024,
+				MessageHandler: MessageHandlerFunc(func(message *Message) error {
+					ids = append(ids, message.MsgId)
+					return nil
+				})}
+			var err error
+			for err == nil {
+				err = reader.Serve()
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+			assert.Equal(t, tt.wantErr, !errors.Is(err, io.EOF), "err: %v", err)
+		})
+	}
+}
+
+func TestReaderMessageHandler_MaxPayloadSize(t *testing.T) {
+	big := `{"content":"` + strings.Repeat("a", 2048) + `"}`
+	for _, framing := range []Framing{FramingJSON, FramingLengthPrefixed} {
+		input := []byte(big)
+		if framing == FramingLengthPrefixed {
+			input = lengthPrefixed(big)
+		}
+		reader := ReaderMessageHandler{Reader: bytes.NewReader(input), Framing: framing, MaxPayloadSize: 1024}
+		_, err := reader.Next()
+		assert.True(t, errors.Is(err, ErrPayloadTooLarge), "framing %d: %v", framing, err)
+	}
+
+	// 多条小消息的总大小超过限制时不受影响
+	small := strings.Repeat(`{"content":"`+strings.Repeat("a", 100)+"\"}\n", 20)
+	reader := ReaderMessageHandler{Reader: strings.NewReader(small), MaxPayloadSize: 256}
+	for i := 0; i < 20; i++ {
+		_, err := reader.Next()
+		assert.Nil(t, err)
+	}
+}
+
+// startTCPListener 启动监听，content 为 "fail" 的消息处理失败
+func startTCPListener(t *testing.T, framing Framing) *TCPMessageListener {
+	listener := NewTCPMessageListener("0")
+	listener.ReadTimeout = time.Second
+	listener.Framing = framing
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() {
+		_ = listener.ListenAndServe(ctx, MessageHandlerFunc(func(message *Message) error {
+			if message.Content == "fail" {
+				return errors.New("handle failed")
+			}
+			return nil
+		}))
+	}()
+	t.Cleanup(func() { _ = listener.Close() })
+	return listener
+}
+
+func TestTCPMessageListener_Ack(t *testing.T) {
+	listener := startTCPListener(t, FramingJSON)
+	conn, err := net.Dial("tcp", listenAddr(t, listener))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+	readAck := func(want string) {
+		ack := make([]byte, len(want))
+		_, err := io.ReadFull(conn, ack)
+		assert.Nil(t, err)
+		assert.Equal(t, want, string(ack))
+	}
+
+	// 持久连接上连续发送，ack 与 wxhelper 约定的一致，不带换行
+	_, _ = conn.Write([]byte("{\"msgId\":1}\n"))
+	readAck("200 OK")
+	_, _ = conn.Write([]byte(`{"msgId":2,"content":"fail"}`))
+	readAck("500 Internal Server Error")
+	_, _ = conn.Write([]byte(`{"msgId":3,`))
+	_ = conn.(*net.TCPConn).CloseWrite()
+	readAck("400 Bad Request")
+	rest, _ := io.ReadAll(conn)
+	assert.Empty(t, rest)
+}
+
+func TestTCPMessageListener_AckLengthPrefixed(t *testing.T) {
+	listener := startTCPListener(t, FramingLengthPrefixed)
+	conn, err := net.Dial("tcp", listenAddr(t, listener))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+	acks := bufio.NewReader(conn)
+
+	// ack 以换行结尾
+	_, _ = conn.Write(lengthPrefixed(`{"msgId":1}`))
+	line, _ := acks.ReadString('\n')
+	assert.Equal(t, "200 OK\n", line)
+	_, _ = conn.Write(lengthPrefixed(`{"msgId":2,"content":"fail"}`))
+	line, _ = acks.ReadString('\n')
+	assert.Equal(t, "500 Internal Server Error\n", line)
+}
+
+func TestTCPMessageListener_ShutdownIdleConn(t *testing.T) {
+	listener := startTCPListener(t, FramingJSON)
+	listener.ReadTimeout = time.Minute
+	conn, err := net.Dial("tcp", listenAddr(t, listener))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+	_, _ = conn.Write([]byte(`{"msgId":1}`))
+	ack := make([]byte, len(ackOK))
+	_, _ = io.ReadFull(conn, ack)
+
+	// 连接保持打开但空闲，Shutdown 直接关闭而不等待读取超时
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	start := time.Now()
+	assert.Nil(t, listener.Shutdown(ctx))
+	assert.Less(t, time.Since(start), time.Second)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = conn.Read(ack)
+	assert.True(t, errors.Is(err, io.EOF), "err: %v", err)
+}
+
+func FuzzReaderMessageHandler(f *testing.F) {
+	f.Add([]byte(`{"msgId":1,"content":"hi"}`), false)
+	f.Add([]byte("{\"msgId\":1}\n{\"msgId\":2}\n"), false)
+	f.Add([]byte(`{"msgId":1,"content":"hi`), false)
+	f.Add([]byte(`{"msgId":"not a number"}`), false)
+	f.Add([]byte(`[1,2,3]`), false)
+	f.Add([]byte{0xff, 0xfe, 0x00}, false)
+	f.Add(lengthPrefixed(`{"msgId":1}`), true)
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, '{'}, true)
+	f.Add(lengthPrefixed(`{"msgId":1}`)[:6], true)
+	f.Fuzz(func(t *testing.T, data []byte, lengthPrefix bool) {
+		framing := FramingJSON
+		if lengthPrefix {
+			framing = FramingLengthPrefixed
+		}
+		reader := ReaderMessageHandler{Reader: bytes.NewReader(data), Framing: framing, MaxPayloadSize: 4096,
+			MessageHandler: MessageHandlerFunc(func(message *Message) error {
+				if message == nil {
+					t.Fatal("nil message")
+				}
+				return nil
+			})}
+		for i := 0; i <= len(data); i++ {
+			if err := reader.Serve(); err != nil {
+				return
+			}
+		}
+		t.Fatalf("reader did not stop after %d messages", len(data)+1)
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"io"
 	"os"
 	"path/filepath"
 	"sync"
@@ -20,7 +21,12 @@ func init() {
 	zerolog.MultiLevelWriter(zerolog.ConsoleWriter{Out: os.Stderr})
 	multi := zerolog.MultiLevelWriter(zerolog.ConsoleWriter{Out: os.Stderr})
 
-	log.Logger = zerolog.New(multi).With().Timestamp().Str("project", projectName).Logger()
+	log.Logger = New(multi)
+}
+
+// New 创建输出到 w 的日志，附带时间戳与项目名
+func New(w io.Writer) zerolog.Logger {
+	return zerolog.New(w).With().Timestamp().Str("project", projectName).Logger()
 }
 
 // Info 定义简化的日志函数
@@ -165,3 +171,56 @@ func (lb *LogBuffer) SetActive(active bool) {
 	defer lb.mu.Unlock()
 	lb.active = active
 }
+
+// FieldLogger 带固定字段的日志输出，用于区分同一进程中的多个客户端
+type FieldLogger struct {
+	logger zerolog.Logger
+}
+
+// NewFieldLogger 基于指定的 zerolog.Logger 创建
+func NewFieldLogger(logger zerolog.Logger) *FieldLogger {
+	return &FieldLogger{logger: logger}
+}
+
+// Default 基于全局 Logger 创建
+func Default() *FieldLogger {
+	return &FieldLogger{logger: log.Logger}
+}
+
+// With 附加固定字段
+func (l *FieldLogger) With(fields map[string]interface{}) *FieldLogger {
+	return &FieldLogger{logger: l.logger.With().Fields(fields).Logger()}
+}
+
+func (l *FieldLogger) emit(event *zerolog.Event, msg string, fields []map[string]interface{}) {
+	for _, field := range fields {
+		for k, v := range field {
+			event = event.Interface(k, v)
+		}
+	}
+	event.Msg(msg)
+}
+
+func (l *FieldLogger) Info(msg string, fields ...map[string]interface{}) {
+	l.emit(l.logger.Info(), msg, fields)
+}
+
+func (l *FieldLogger) Debug(msg string, fields ...map[string]interface{}) {
+	l.emit(l.logger.Debug(), msg, fields)
+}
+
+func (l *FieldLogger) Warn(msg string, fields ...map[string]interface{}) {
+	l.emit(l.logger.Warn(), msg, fields)
+}
+
+func (l *FieldLogger) WarnWithErr(err error, msg string, fields ...map[string]interface{}) {
+	l.emit(l.logger.Warn().Err(err), msg, fields)
+}
+
+func (l *FieldLogger) Error(msg string, fields ...map[string]interface{}) {
+	l.emit(l.logger.Error(), msg, fields)
+}
+
+func (l *FieldLogger) ErrorWithErr(err error, msg string, fields ...map[string]interface{}) {
+	l.emit(l.logger.Error().Err(err), msg, fields)
+}
@@ -0,0 +1,337 @@
+// Package wxhelper_sdk
+// @Author Clover
+// @Data 2025/1/17 下午3:40:00
+// @Desc 多账号管理：同一进程中运行多个 wxhelper 实例，合并消息流并按账号路由发送
+package wxhelper_sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"wxhelper-sdk/logging"
+)
+
+var (
+	ErrAccountNotFound    = errors.New("account not found")
+	ErrDuplicateAccount   = errors.New("account already exists")
+	ErrInvalidAccountName = errors.New("invalid account name")
+	ErrDuplicateListen    = errors.New("listen address already in use by another account")
+	ErrManagerClosed      = errors.New("manager closed")
+)
+
+// Manager 管理多个账号的客户端，每个客户端使用独立的监听端口、缓存目录（<缓存目录>/<name>）与日志字段
+type Manager struct {
+	opts []Option // 所有账号共用的配置
+	log  *logging.FieldLogger
+
+	mu      sync.RWMutex
+	names   []string // 添加顺序
+	clients map[string]*Client
+	running bool
+	debug   bool
+	closed  bool
+
+	ctx        context.Context
+	stop       context.CancelFunc
+	msgBuffer  *MessageBuffer // 合并后的消息流
+	forwarding sync.WaitGroup
+	serving    sync.WaitGroup
+	dropped    []*Message // 关闭时未能转入合并消息流的消息
+}
+
+// NewManager 创建多账号管理器，opts 为所有账号共用的配置（如 WithAPIBaseURL 之外的日志、缓存目录等）
+func NewManager(opts ...Option) *Manager {
+	o := optionsFromEnv()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	log := o.logger
+	if log == nil {
+		log = logging.Default()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		opts:      opts,
+		log:       log,
+		clients:   make(map[string]*Client),
+		ctx:       ctx,
+		stop:      cancel,
+		msgBuffer: newMessageBuffer(o.bufferSize, log),
+	}
+}
+
+// withAccount 按账号隔离缓存目录与日志字段，需作为最后一个 Option
+func withAccount(name string) Option {
+	return func(o *clientOptions) {
+		o.cacheDir = filepath.Join(o.cacheDir, name)
+		if o.logger == nil {
+			o.logger = logging.Default()
+		}
+		o.logger = o.logger.With(map[string]interface{}{"account": name})
+	}
+}
+
+// listenAddrOf 监听者的本地地址，自定义监听者、挂载模式或随机端口返回空
+func listenAddrOf(listener MessageListener) string {
+	var addr string
+	switch l := listener.(type) {
+	case *TCPMessageListener:
+		addr = l.Addr
+	case *HTTPMessageListener:
+		addr = l.Addr
+	}
+	if _, port, _ := strings.Cut(addr, ":"); port == "0" {
+		return ""
+	}
+	return addr
+}
+
+// Add 添加账号 <name: 账号名，用于缓存目录与日志，登录前即可通过 name 获取客户端>，
+// opts 在共用配置之后应用，通常需要指定 WithAPIBaseURL、WithListenAddr、WithHookURL
+func (m *Manager) Add(name string, opts ...Option) (*Client, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAccountName, name)
+	}
+	if err := m.checkAdd(name); err != nil {
+		return nil, err
+	}
+	all := make([]Option, 0, len(m.opts)+len(opts)+1)
+	all = append(append(append(all, m.opts...), opts...), withAccount(name))
+	client := NewClient(all...)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	err := m.checkAddLocked(name)
+	if addr := listenAddrOf(client.listener); err == nil && addr != "" {
+		for other, c := range m.clients {
+			if listenAddrOf(c.listener) == addr {
+				err = fmt.Errorf("%w: %s (%s)", ErrDuplicateListen, addr, other)
+				break
+			}
+		}
+	}
+	if err != nil {
+		client.stop() // 未运行的客户端只需停止发送队列等后台任务
+		return nil, err
+	}
+	m.names = append(m.names, name)
+	m.clients[name] = client
+	if m.running {
+		m.start(client)
+	}
+	return client, nil
+}
+
+func (m *Manager) checkAdd(name string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.checkAddLocked(name)
+}
+
+// checkAddLocked 是否可以添加账号，需持有 m.mu
+func (m *Manager) checkAddLocked(name string) error {
+	if m.closed {
+		return ErrManagerClosed
+	}
+	if _, exists := m.clients[name]; exists {
+		return fmt.Errorf("%w: %s", ErrDuplicateAccount, name)
+	}
+	return nil
+}
+
+// Run 运行所有账号，之后添加的账号会立即运行
+func (m *Manager) Run(debug bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.running || m.closed {
+		return
+	}
+	m.running, m.debug = true, debug
+	for _, name := range m.names {
+		m.start(m.clients[name])
+	}
+}
+
+// start 运行客户端并将其消息转入合并消息流，需持有 m.mu
+func (m *Manager) start(client *Client) {
+	m.forwarding.Add(1)
+	go m.forward(client)
+	go client.Run(m.debug)
+}
+
+func (m *Manager) forward(client *Client) {
+	defer m.forwarding.Done()
+	for {
+		msg, err := client.msgBuffer.Get(client.ctx)
+		if err != nil {
+			return
+		}
+		// 合并消息流已满时阻塞该账号的转发直到被消费，客户端停止时记录未转发的消息
+		select {
+		case m.msgBuffer.msgCH <- msg:
+		case <-client.ctx.Done():
+			m.mu.Lock()
+			m.dropped = append(m.dropped, msg)
+			m.mu.Unlock()
+			return
+		}
+	}
+}
+
+// Client 按账号名或已登录账号的 wxid 获取客户端
+func (m *Manager) Client(account string) (*Client, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if client, ok := m.clients[account]; ok {
+		return client, nil
+	}
+	for _, name := range m.names {
+		client := m.clients[name]
+		if self := client.Self(); self != nil && self.Wxid == account {
+			return client, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, account)
+}
+
+// Clients 按添加顺序返回所有客户端
+func (m *Manager) Clients() []*Client {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	clients := make([]*Client, 0, len(m.names))
+	for _, name := range m.names {
+		clients = append(clients, m.clients[name])
+	}
+	return clients
+}
+
+// GetMsg 从合并消息流获取消息，通过 Message.Account 区分接收的账号，Reply 会经由接收的账号发送
+func (m *Manager) GetMsg() (*Message, error) {
+	if m.isClosed() {
+		return nil, ErrManagerClosed
+	}
+	return m.msgBuffer.Get(m.ctx)
+}
+
+// Serve 以推送的方式将所有账号的消息分发给 handler，阻塞直到 ctx 结束
+func (m *Manager) Serve(ctx context.Context, handler MessageHandler, opts ...ServeOption) error {
+	if m.isClosed() {
+		return ErrManagerClosed
+	}
+	m.serving.Add(1)
+	defer m.serving.Done()
+	return serveBuffer(ctx, m.ctx, m.msgBuffer, handler, m.log, newServeOptions(opts))
+}
+
+// SendText 以指定账号（账号名或 wxid）发送文本消息
+func (m *Manager) SendText(ctx context.Context, account string, to string, content string) error {
+	client, err := m.Client(account)
+	if err != nil {
+		return err
+	}
+	return client.SendText(ctx, to, content)
+}
+
+// SendImage 以指定账号发送图片 <imagePath: wxhelper 所在机器上的图片路径>
+func (m *Manager) SendImage(ctx context.Context, account string, to string, imagePath string) error {
+	client, err := m.Client(account)
+	if err != nil {
+		return err
+	}
+	return client.SendImage(ctx, to, imagePath)
+}
+
+// SendFile 以指定账号发送文件 <filePath: wxhelper 所在机器上的文件路径>
+func (m *Manager) SendFile(ctx context.Context, account string, to string, filePath string) error {
+	client, err := m.Client(account)
+	if err != nil {
+		return err
+	}
+	return client.SendFile(ctx, to, filePath)
+}
+
+// SendAtText 以指定账号在群聊中发送@消息
+func (m *Manager) SendAtText(ctx context.Context, account string, chatRoomID string, wxids []string, content string) error {
+	client, err := m.Client(account)
+	if err != nil {
+		return err
+	}
+	return client.SendAtText(ctx, chatRoomID, wxids, content)
+}
+
+// Forward 以指定账号转发消息 <msgID: 该账号收到的原消息 MsgId>
+func (m *Manager) Forward(ctx context.Context, account string, to string, msgID int64) error {
+	client, err := m.Client(account)
+	if err != nil {
+		return err
+	}
+	return client.Forward(ctx, to, msgID)
+}
+
+func (m *Manager) isClosed() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.closed
+}
+
+// Close 并发关闭所有账号，合并消息流中未被取走的消息按账号持久化到各自的缓存目录
+func (m *Manager) Close(ctx context.Context) error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	clients := make(map[string]*Client, len(m.clients))
+	for name, client := range m.clients {
+		clients[name] = client
+	}
+	m.mu.Unlock()
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for name, client := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := client.Close(ctx); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("close account %s: %w", name, err))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 客户端关闭后不再有新消息转入，等待合并消息流被取走
+	m.forwarding.Wait()
+	waitDrain(ctx, m.msgBuffer)
+	m.stop()
+	if err := waitGroupWithContext(ctx, &m.serving); err != nil {
+		errs = append(errs, fmt.Errorf("wait serving handlers: %w", err))
+	}
+
+	m.mu.Lock()
+	remaining := append(m.dropped, m.msgBuffer.drain()...)
+	m.dropped = nil
+	m.mu.Unlock()
+	byClient := make(map[*Client][]*Message)
+	for _, msg := range remaining {
+		byClient[msg.client] = append(byClient[msg.client], msg)
+	}
+	for client, msgs := range byClient {
+		path, err := persistMessages(client.cache.Dir(), msgs)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("persist %d pending messages: %w", len(msgs), err))
+			continue
+		}
+		client.log.Warn("pending messages persisted", map[string]interface{}{"count": len(msgs), "path": path})
+	}
+	return errors.Join(errs...)
+}
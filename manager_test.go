@@ -0,0 +1,99 @@
+package wxhelper_sdk
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestManager(t *testing.T) {
+	fakeA, fakeB := &fakeWxHelper{}, &fakeWxHelper{}
+	fakeA.set(true, "wxid_a")
+	fakeB.set(true, "wxid_b")
+	serverA, serverB := httptest.NewServer(fakeA), httptest.NewServer(fakeB)
+	defer serverA.Close()
+	defer serverB.Close()
+	cacheDir := t.TempDir()
+
+	manager := NewManager(WithCacheDir(cacheDir), WithBufferSize(1))
+	listenerA, listenerB := NewChanMessageListener(4), NewChanMessageListener(4)
+	clientA, err := manager.Add("a", WithAPIBaseURL(serverA.URL), WithListener(listenerA))
+	assert.Nil(t, err)
+	_, err = manager.Add("b", WithAPIBaseURL(serverB.URL), WithListener(listenerB))
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(cacheDir, "a"), clientA.cache.Dir())
+
+	_, err = manager.Add("a")
+	assert.True(t, errors.Is(err, ErrDuplicateAccount))
+	_, err = manager.Add("../c")
+	assert.True(t, errors.Is(err, ErrInvalidAccountName))
+	_, err = manager.Add("b", WithListenAddr("19099"))
+	assert.True(t, errors.Is(err, ErrDuplicateAccount))
+	// 重复的账号名在创建客户端之前拒绝
+	rejected := NewChanMessageListener(1)
+	_, err = manager.Add("b", WithListener(rejected))
+	assert.True(t, errors.Is(err, ErrDuplicateAccount))
+	assert.Nil(t, rejected.log)
+
+	manager.Run(false)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	for _, name := range []string{"a", "b"} {
+		client, err := manager.Client(name)
+		if assert.Nil(t, err) {
+			assert.Nil(t, client.WaitLogin(ctx))
+		}
+	}
+
+	// 合并消息流：按接收账号标记
+	assert.Nil(t, listenerB.Send(ctx, &Message{MsgId: 2, Type: MsgTypeText, FromUser: "wxid_x", Content: "to b"}))
+	msg, err := manager.GetMsg()
+	if assert.Nil(t, err) {
+		assert.Equal(t, "wxid_b", msg.Account().Wxid)
+	}
+
+	// 按 wxid 路由
+	client, err := manager.Client("wxid_b")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "wxid_b", client.Self().Wxid)
+	}
+	_, err = manager.Client("wxid_z")
+	assert.True(t, errors.Is(err, ErrAccountNotFound))
+	assert.True(t, errors.Is(manager.SendText(ctx, "wxid_z", "wxid_x", "hi"), ErrAccountNotFound))
+	assert.True(t, errors.Is(manager.SendAtText(ctx, "wxid_z", "123@chatroom", []string{"wxid_x"}, "hi"), ErrAccountNotFound))
+	assert.True(t, errors.Is(manager.Forward(ctx, "wxid_z", "wxid_x", 1), ErrAccountNotFound))
+	assert.Nil(t, manager.SendAtText(ctx, "b", "123@chatroom", []string{"wxid_x"}, "hi"))
+	var sendErr *SendError
+	if assert.True(t, errors.As(manager.Forward(ctx, "wxid_b", "wxid_x", 1), &sendErr)) { // 模拟接口未实现转发
+		assert.Equal(t, "forward", sendErr.Op)
+	}
+
+	// 未取走的消息按账号持久化
+	assert.Nil(t, listenerA.Send(ctx, &Message{MsgId: 1, Type: MsgTypeText, FromUser: "wxid_x", Content: "pending"}))
+	assert.Eventually(t, func() bool { return manager.msgBuffer.Len() == 1 }, time.Second, 10*time.Millisecond)
+	assert.Nil(t, manager.Close(ctx))
+	files, _ := filepath.Glob(filepath.Join(cacheDir, "a", "pending_messages_*.jsonl"))
+	if assert.Len(t, files, 1) {
+		data, _ := os.ReadFile(files[0])
+		assert.Contains(t, string(data), `"content":"pending"`)
+	}
+	_, err = manager.GetMsg()
+	assert.Equal(t, ErrManagerClosed, err)
+}
+
+func TestManager_DuplicateListen(t *testing.T) {
+	manager := NewManager(WithCacheDir(t.TempDir()))
+	_, err := manager.Add("a", WithListenAddr("19099"))
+	assert.Nil(t, err)
+	_, err = manager.Add("b", WithListenAddr("19099"))
+	assert.True(t, errors.Is(err, ErrDuplicateListen))
+	_, err = manager.Add("c", WithListenAddr("0"))
+	assert.Nil(t, err)
+	_, err = manager.Add("d", WithListenAddr("0"))
+	assert.Nil(t, err)
+}
@@ -8,6 +8,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 	"wxhelper-sdk/inner/manager"
 	"wxhelper-sdk/inner/utils"
@@ -29,23 +30,93 @@ type Message struct {
 	FileInfo           *manager.FileInfo `json:"-"` // 本地保存的文件信息
 
 	account *Account
+	client  *Client // 接收该消息的客户端，用于回复
 }
 
-func (m *Message) handleFileTypeMsg() {
+// Account 接收该消息的账号（多账号时用于区分消息来源），消息未经客户端接收时为 nil
+func (m *Message) Account() *Account {
+	return m.account
+}
+
+// IsGroup 是否为群聊消息
+func (m *Message) IsGroup() bool {
+	return isChatRoomID(m.FromUser) || isChatRoomID(m.ToUser)
+}
+
+// ChatRoomID 群聊消息所在的群id，私聊消息返回空
+func (m *Message) ChatRoomID() string {
+	if isChatRoomID(m.FromUser) {
+		return m.FromUser
+	}
+	if isChatRoomID(m.ToUser) {
+		return m.ToUser
+	}
+	return ""
+}
+
+// Sender 消息的实际发送者：群消息为群内成员 wxid，私聊为 FromUser
+// 系统消息、撤回通知以及无法确定来自当前账号的无前缀群消息返回空
+func (m *Message) Sender() string {
+	if m.Type == MsgTypeSys || m.Type == MsgTypeRecalled {
+		return ""
+	}
+	if !m.IsGroup() {
+		return m.FromUser
+	}
+	if sender, _, ok := m.splitGroupContent(); ok {
+		return sender
+	}
+	// 自己在群内发送的消息没有发送者前缀
+	if !isChatRoomID(m.FromUser) {
+		return m.FromUser
+	}
+	// 同步自手机的文本没有前缀且 FromUser 为群；无前缀的 XML 内容（系统、应用消息等）无法确定发送者
+	if m.account != nil && !strings.HasPrefix(strings.TrimSpace(m.Content), "<") {
+		return m.account.Wxid
+	}
+	return ""
+}
+
+// Text 去除群消息发送者前缀后的内容（文本、图片及 XML 消息均适用）
+func (m *Message) Text() string {
+	if !m.IsGroup() {
+		return m.Content
+	}
+	if _, body, ok := m.splitGroupContent(); ok {
+		return body
+	}
+	return m.Content
+}
+
+// splitGroupContent 群消息 Content 形如 "wxid_xxx:\n内容"，拆分为发送者与内容
+func (m *Message) splitGroupContent() (sender string, body string, ok bool) {
+	idx := strings.Index(m.Content, ":\n")
+	if idx <= 0 {
+		return "", m.Content, false
+	}
+	sender = m.Content[:idx]
+	if strings.ContainsAny(sender, " \t\r\n<>\"'") {
+		return "", m.Content, false
+	}
+	return sender, m.Content[idx+2:], true
+}
+
+func (m *Message) handleFileTypeMsg(cacheManager manager.ICacheManager, log *logging.FieldLogger) {
 	switch m.Type {
 	case MsgTypeImage:
-		m.handleImgTypeMsg()
+		m.handleImgTypeMsg(cacheManager, log)
 	default:
-		logging.Warn("Unknown message type. Skip!!")
-
+		if !m.Type.Known() {
+			log.Debug("Unknown message type. Skip!!", map[string]interface{}{"type": m.Type, "msgId": m.MsgId})
+		}
 	}
 }
 
 // 处理图片数据
-func (m *Message) handleImgTypeMsg() {
+func (m *Message) handleImgTypeMsg(cacheManager manager.ICacheManager, log *logging.FieldLogger) {
 	data, err := utils.DecodeBase64(m.Base64Img)
 	if err != nil {
-		logging.ErrorWithErr(err, "DecodeBase64 failed")
+		log.ErrorWithErr(err, "DecodeBase64 failed", map[string]interface{}{"msgId": m.MsgId})
 		return
 	}
 	var filename = fmt.Sprintf("%s_%d", m.FromUser, m.MsgId)
@@ -56,10 +127,9 @@ func (m *Message) handleImgTypeMsg() {
 	//	ext := imgutil.GetEtxByFileType(fileType)
 	//	filename = filename + ext
 	//}
-	cacheManager := manager.GetCacheManager()
 	fileInfo, err := cacheManager.Save(filename+".png", true, data)
 	if err != nil {
-		logging.ErrorWithErr(err, "SaveFileInfo failed")
+		log.ErrorWithErr(err, "SaveFileInfo failed", map[string]interface{}{"msgId": m.MsgId})
 	}
 	m.FileInfo = fileInfo
 	return
@@ -71,12 +141,18 @@ var (
 
 type MessageBuffer struct {
 	msgCH chan *Message // 原始消息输入通道
+	log   *logging.FieldLogger
 }
 
 // NewMessageBuffer 创建消息缓冲区 <缓冲大小>
 func NewMessageBuffer(bufferSize int) *MessageBuffer {
+	return newMessageBuffer(bufferSize, logging.Default())
+}
+
+func newMessageBuffer(bufferSize int, log *logging.FieldLogger) *MessageBuffer {
 	mb := &MessageBuffer{
 		msgCH: make(chan *Message, bufferSize),
+		log:   log,
 	}
 	return mb
 }
@@ -89,10 +165,10 @@ func (mb *MessageBuffer) Put(ctx context.Context, msg *Message) error {
 		case <-ctx.Done():
 			return ctx.Err()
 		case mb.msgCH <- msg:
-			logging.Info("put message to buffer")
+			mb.log.Debug("put message to buffer", map[string]interface{}{"msgId": msg.MsgId})
 			return nil
 		default:
-			logging.Warn("message buffer is full, retrying", map[string]interface{}{fmt.Sprintf("%d", i+1): retries})
+			mb.log.Warn("message buffer is full, retrying", map[string]interface{}{fmt.Sprintf("%d", i+1): retries})
 		}
 
 		// Optional: add a small delay before retrying to prevent busy-waiting
@@ -107,7 +183,35 @@ func (mb *MessageBuffer) Get(ctx context.Context) (*Message, error) {
 	case <-ctx.Done():
 		return nil, ctx.Err()
 	case pair := <-mb.msgCH:
-		logging.Info("retrieved message pair from buffer")
+		mb.log.Debug("retrieved message from buffer", map[string]interface{}{"msgId": pair.MsgId})
 		return pair, nil
 	}
 }
+
+// tryGet 非阻塞地取出一条消息，缓冲区为空时返回 false
+func (mb *MessageBuffer) tryGet() (*Message, bool) {
+	select {
+	case msg := <-mb.msgCH:
+		return msg, true
+	default:
+		return nil, false
+	}
+}
+
+// Len 缓冲区中的消息数量
+func (mb *MessageBuffer) Len() int {
+	return len(mb.msgCH)
+}
+
+// drain 非阻塞地取出缓冲区中剩余的全部消息
+func (mb *MessageBuffer) drain() []*Message {
+	var msgs []*Message
+	for {
+		select {
+		case msg := <-mb.msgCH:
+			msgs = append(msgs, msg)
+		default:
+			return msgs
+		}
+	}
+}
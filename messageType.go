@@ -4,10 +4,66 @@
 // @Desc
 package wxhelper_sdk
 
+import "strconv"
+
 type MsgType int
 
 const (
-	MsgTypeUnknown MsgType = iota
-	MsgTypeTest
-	MsgTypeImage = 3
+	MsgTypeUnknown      MsgType = 0
+	MsgTypeText         MsgType = 1     // 文本
+	MsgTypeImage        MsgType = 3     // 图片
+	MsgTypeVoice        MsgType = 34    // 语音
+	MsgTypeFriendVerify MsgType = 37    // 好友验证（好友请求）
+	MsgTypePossible     MsgType = 40    // 可能认识的朋友
+	MsgTypeCard         MsgType = 42    // 名片
+	MsgTypeVideo        MsgType = 43    // 视频
+	MsgTypeEmoji        MsgType = 47    // 表情
+	MsgTypeLocation     MsgType = 48    // 位置
+	MsgTypeApp          MsgType = 49    // 应用消息（链接、文件、小程序、引用、转账等 XML 消息）
+	MsgTypeVoip         MsgType = 50    // 音视频通话
+	MsgTypeStatusNotify MsgType = 51    // 状态同步
+	MsgTypeVoipNotify   MsgType = 52    // 通话通知
+	MsgTypeVoipInvite   MsgType = 53    // 通话邀请
+	MsgTypeMicroVideo   MsgType = 62    // 小视频
+	MsgTypeSysNotice    MsgType = 9999  // 系统通知
+	MsgTypeSys          MsgType = 10000 // 系统消息（入群、改群名等）
+	MsgTypeRecalled     MsgType = 10002 // 撤回等 sysmsg 消息
+
+	// Deprecated: 使用 MsgTypeText
+	MsgTypeTest = MsgTypeText
 )
+
+var msgTypeNames = map[MsgType]string{
+	MsgTypeUnknown:      "Unknown",
+	MsgTypeText:         "Text",
+	MsgTypeImage:        "Image",
+	MsgTypeVoice:        "Voice",
+	MsgTypeFriendVerify: "FriendVerify",
+	MsgTypePossible:     "Possible",
+	MsgTypeCard:         "Card",
+	MsgTypeVideo:        "Video",
+	MsgTypeEmoji:        "Emoji",
+	MsgTypeLocation:     "Location",
+	MsgTypeApp:          "App",
+	MsgTypeVoip:         "Voip",
+	MsgTypeStatusNotify: "StatusNotify",
+	MsgTypeVoipNotify:   "VoipNotify",
+	MsgTypeVoipInvite:   "VoipInvite",
+	MsgTypeMicroVideo:   "MicroVideo",
+	MsgTypeSysNotice:    "SysNotice",
+	MsgTypeSys:          "Sys",
+	MsgTypeRecalled:     "Recalled",
+}
+
+func (t MsgType) String() string {
+	if name, ok := msgTypeNames[t]; ok {
+		return name
+	}
+	return "MsgType(" + strconv.Itoa(int(t)) + ")"
+}
+
+// Known 是否为已知的消息类型
+func (t MsgType) Known() bool {
+	_, ok := msgTypeNames[t]
+	return ok && t != MsgTypeUnknown
+}
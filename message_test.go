@@ -134,3 +134,79 @@ func TestMessageBuffer_BufferFullRetry(t *testing.T) {
 	err = mb.Put(ctx, msg2)
 	assert.Equal(t, err, ErrBufferFull, "expected ErrBufferFull when buffer is full")
 }
+
+func TestMessage_GroupSplit(t *testing.T) {
+	self := &Account{Wxid: "wxid_bot"}
+	tests := []struct {
+		name       string
+		msg        *Message
+		isGroup    bool
+		chatRoomID string
+		sender     string
+		text       string
+	}{
+		{
+			name:    "private text",
+			msg:     &Message{FromUser: "wxid_alice", ToUser: "wxid_bot", Content: "hello"},
+			isGroup: false, chatRoomID: "", sender: "wxid_alice", text: "hello",
+		},
+		{
+			name:    "private text contains colon newline",
+			msg:     &Message{FromUser: "wxid_alice", ToUser: "wxid_bot", Content: "todo:\nbuy milk"},
+			isGroup: false, chatRoomID: "", sender: "wxid_alice", text: "todo:\nbuy milk",
+		},
+		{
+			name:    "group text",
+			msg:     &Message{FromUser: "34757816141@chatroom", ToUser: "wxid_bot", Content: "wxid_alice:\nhello\nworld"},
+			isGroup: true, chatRoomID: "34757816141@chatroom", sender: "wxid_alice", text: "hello\nworld",
+		},
+		{
+			name: "group image xml",
+			msg: &Message{FromUser: "34757816141@chatroom", ToUser: "wxid_bot", Type: MsgTypeImage,
+				Content: "wxid_alice:\n<?xml version=\"1.0\"?>\n<msg>\n\t<img aeskey=\"abc\" length=\"1024\" />\n</msg>\n"},
+			isGroup: true, chatRoomID: "34757816141@chatroom", sender: "wxid_alice",
+			text: "<?xml version=\"1.0\"?>\n<msg>\n\t<img aeskey=\"abc\" length=\"1024\" />\n</msg>\n",
+		},
+		{
+			name: "group appmsg without xml header",
+			msg: &Message{FromUser: "34757816141@chatroom", ToUser: "wxid_bot", Type: MsgTypeApp,
+				Content: "alice-custom_01:\n<msg><appmsg><title>t</title></appmsg></msg>"},
+			isGroup: true, chatRoomID: "34757816141@chatroom", sender: "alice-custom_01",
+			text: "<msg><appmsg><title>t</title></appmsg></msg>",
+		},
+		{
+			name:    "group message sent by self",
+			msg:     &Message{FromUser: "34757816141@chatroom", ToUser: "", Content: "I am the bot"},
+			isGroup: true, chatRoomID: "34757816141@chatroom", sender: "wxid_bot", text: "I am the bot",
+		},
+		{
+			name:    "self to group as ToUser",
+			msg:     &Message{FromUser: "wxid_bot", ToUser: "34757816141@chatroom", Content: "ok"},
+			isGroup: true, chatRoomID: "34757816141@chatroom", sender: "wxid_bot", text: "ok",
+		},
+		{
+			name:    "group content without prefix looks like xml",
+			msg:     &Message{FromUser: "34757816141@chatroom", Content: "<msg a=\"x:\ny\"/>"},
+			isGroup: true, chatRoomID: "34757816141@chatroom", sender: "", text: "<msg a=\"x:\ny\"/>",
+		},
+		{
+			name:    "group system message",
+			msg:     &Message{FromUser: "34757816141@chatroom", Type: MsgTypeSys, Content: "\"alice\"加入了群聊"},
+			isGroup: true, chatRoomID: "34757816141@chatroom", sender: "", text: "\"alice\"加入了群聊",
+		},
+		{
+			name:    "private recalled",
+			msg:     &Message{FromUser: "wxid_alice", ToUser: "wxid_bot", Type: MsgTypeRecalled, Content: "<sysmsg type=\"revokemsg\"/>"},
+			isGroup: false, chatRoomID: "", sender: "", text: "<sysmsg type=\"revokemsg\"/>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.msg.account = self
+			assert.Equal(t, tt.isGroup, tt.msg.IsGroup())
+			assert.Equal(t, tt.chatRoomID, tt.msg.ChatRoomID())
+			assert.Equal(t, tt.sender, tt.msg.Sender())
+			assert.Equal(t, tt.text, tt.msg.Text())
+		})
+	}
+}
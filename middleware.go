@@ -0,0 +1,91 @@
+// Package wxhelper_sdk
+// @Author Clover
+// @Data 2025/1/12 下午8:30:00
+// @Desc 常用中间件
+package wxhelper_sdk
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+	"wxhelper-sdk/logging"
+)
+
+// logger 接收该消息的客户端的日志（包含 WithLogger 及账号字段），消息未经客户端接收时使用全局日志
+func (m *Message) logger() *logging.FieldLogger {
+	if m.client != nil && m.client.log != nil {
+		return m.client.log
+	}
+	return logging.Default()
+}
+
+var (
+	ErrHandlerPanic = errors.New("message handler panic")
+	ErrRateLimited  = errors.New("message rate limited")
+)
+
+// Recover 捕获 handler 中的 panic 并转为错误
+func Recover() Middleware {
+	return func(next MessageHandler) MessageHandler {
+		return MessageHandlerFunc(func(message *Message) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					message.logger().Error("message handler panic", map[string]interface{}{
+						"panic": r, "msgId": message.MsgId, "stack": string(debug.Stack()),
+					})
+					err = fmt.Errorf("%w: %v", ErrHandlerPanic, r)
+				}
+			}()
+			return next.HandleMessage(message)
+		})
+	}
+}
+
+// Logging 记录每条消息的处理耗时与结果
+func Logging() Middleware {
+	return func(next MessageHandler) MessageHandler {
+		return MessageHandlerFunc(func(message *Message) error {
+			start := time.Now()
+			err := next.HandleMessage(message)
+			fields := map[string]interface{}{
+				"msgId":    message.MsgId,
+				"type":     message.Type,
+				"from":     message.FromUser,
+				"duration": time.Since(start).String(),
+			}
+			if err != nil {
+				message.logger().ErrorWithErr(err, "handle message failed", fields)
+			} else {
+				message.logger().Info("handle message", fields)
+			}
+			return err
+		})
+	}
+}
+
+// RateLimit 按会话限流 <rate: 每秒允许的消息数>, <burst: 突发数量>，超出限制返回 ErrRateLimited
+func RateLimit(rate float64, burst int) Middleware {
+	limiter := newKeyedLimiter(rate, burst)
+	return func(next MessageHandler) MessageHandler {
+		return MessageHandlerFunc(func(message *Message) error {
+			if !limiter.allow(message.conversationID()) {
+				return ErrRateLimited
+			}
+			return next.HandleMessage(message)
+		})
+	}
+}
+
+// Auth 仅放行满足条件的消息，其余消息静默丢弃
+func Auth(allow Predicate) Middleware {
+	return func(next MessageHandler) MessageHandler {
+		return MessageHandlerFunc(func(message *Message) error {
+			if !allow(message) {
+				message.logger().Debug("message rejected by auth", map[string]interface{}{"msgId": message.MsgId, "from": message.FromUser})
+				return nil
+			}
+			return next.HandleMessage(message)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+// Package wxhelper_sdk
+// @Author Clover
+// @Data 2025/1/13 下午5:40:00
+// @Desc 消息 Signature 中 <msgsource> 的解析
+package wxhelper_sdk
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+const atAllWxid = "notify@all" // @所有人
+
+// MsgSource 消息来源信息 <msgsource>
+type MsgSource struct {
+	AtUserList  string `xml:"atuserlist"`  // 被@的成员，逗号分隔
+	Silence     int    `xml:"silence"`     // 群是否开启消息免打扰
+	MemberCount int    `xml:"membercount"` // 群成员数
+	Signature   string `xml:"signature"`
+	Pua         int    `xml:"pua"`
+}
+
+// ParseMsgSource 解析 <msgsource> XML
+func ParseMsgSource(data string) (*MsgSource, error) {
+	data = strings.TrimSpace(data)
+	var src MsgSource
+	if data == "" {
+		return &src, nil
+	}
+	if err := xml.Unmarshal([]byte(data), &src); err != nil {
+		return nil, fmt.Errorf("%w: msgsource: %w", ErrPayloadDecode, err)
+	}
+	return &src, nil
+}
+
+// AtUsers 被@的成员 wxid 列表
+func (s *MsgSource) AtUsers() []string {
+	var wxids []string
+	for _, wxid := range strings.Split(s.AtUserList, ",") {
+		if wxid = strings.TrimSpace(wxid); wxid != "" {
+			wxids = append(wxids, wxid)
+		}
+	}
+	return wxids
+}
+
+// MsgSource 解析消息的 Signature 字段
+func (m *Message) MsgSource() (*MsgSource, error) {
+	return ParseMsgSource(m.Signature)
+}
+
+// MentionedWxids 消息中被@的成员 wxid，@所有人时包含 "notify@all"
+func (m *Message) MentionedWxids() []string {
+	src, err := m.MsgSource()
+	if err != nil {
+		return nil
+	}
+	return src.AtUsers()
+}
+
+// IsAtMe 是否@了当前登录账号（@所有人不计入）
+func (m *Message) IsAtMe() bool {
+	if m.account == nil || m.account.Wxid == "" {
+		return false
+	}
+	for _, wxid := range m.MentionedWxids() {
+		if wxid == m.account.Wxid {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAtAll 是否@所有人
+func (m *Message) IsAtAll() bool {
+	for _, wxid := range m.MentionedWxids() {
+		if wxid == atAllWxid {
+			return true
+		}
+	}
+	return false
+}
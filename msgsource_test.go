@@ -0,0 +1,57 @@
+package wxhelper_sdk
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMessage_Mentions(t *testing.T) {
+	self := &Account{Wxid: "wxid_bot"}
+	tests := []struct {
+		name      string
+		signature string
+		wantList  []string
+		wantAtMe  bool
+		wantAtAll bool
+	}{
+		{
+			name:      "at me",
+			signature: "<msgsource>\n\t<atuserlist><![CDATA[,wxid_bot,wxid_other]]></atuserlist>\n\t<silence>0</silence>\n\t<membercount>12</membercount>\n</msgsource>",
+			wantList:  []string{"wxid_bot", "wxid_other"},
+			wantAtMe:  true,
+		},
+		{
+			name:      "at all",
+			signature: "<msgsource><atuserlist>notify@all</atuserlist><membercount>12</membercount></msgsource>",
+			wantList:  []string{"notify@all"},
+			wantAtAll: true,
+		},
+		{
+			name:      "no mention",
+			signature: "<msgsource><silence>1</silence><membercount>3</membercount></msgsource>",
+		},
+		{
+			name:      "empty",
+			signature: "",
+		},
+		{
+			name:      "malformed",
+			signature: "<msgsource><atuserlist>wxid_bot",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &Message{FromUser: "123@chatroom", Signature: tt.signature, account: self}
+			assert.Equal(t, tt.wantList, msg.MentionedWxids())
+			assert.Equal(t, tt.wantAtMe, msg.IsAtMe())
+			assert.Equal(t, tt.wantAtAll, msg.IsAtAll())
+		})
+	}
+}
+
+func TestParseMsgSource(t *testing.T) {
+	src, err := ParseMsgSource("<msgsource><silence>1</silence><membercount>25</membercount></msgsource>")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, src.Silence)
+	assert.Equal(t, 25, src.MemberCount)
+}
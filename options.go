@@ -0,0 +1,204 @@
+// Package wxhelper_sdk
+// @Author Clover
+// @Data 2025/1/16 下午3:00:00
+// @Desc NewClient 的可选配置，未设置的项依次回退到环境变量与默认值
+package wxhelper_sdk
+
+import (
+	"fmt"
+	"github.com/eatmoreapple/env"
+	"github.com/rs/zerolog"
+	"net/http"
+	"time"
+	"wxhelper-sdk/inner/utils"
+	"wxhelper-sdk/logging"
+)
+
+const (
+	DefaultBufferSize       = 100
+	DefaultServeConcurrency = 64 // Serve 同时处理的消息数上限
+)
+
+// Option NewClient 的配置项
+type Option func(o *clientOptions)
+
+type clientOptions struct {
+	listenAddr      string // 本地监听端口
+	apiBaseURL      string // wxhelper http api 地址
+	hookURL         string // tcp 模式下 wxhelper 推送消息的地址 host:port
+	hookMode        string // tcp 或 http
+	httpHookURL     string // http 模式下 wxhelper 推送消息的地址
+	hookTimeout     time.Duration
+	httpClient      *http.Client
+	timeout         time.Duration
+	retry           RetryPolicy
+	observer        func(call APICall)
+	cacheDir        string
+	retention       time.Duration // 缓存保留时间，0 表示不清理
+	logger          *logging.FieldLogger
+	bufferSize      int
+	listener        MessageListener
+	loginPolicy     LoginPolicy
+	contactRefresh  time.Duration    // 联系人定期刷新间隔，0 表示仅在登录时刷新
+	chatRoomTTL     time.Duration    // 群信息缓存有效期，0 表示仅在成员变动时失效
+	revokeRetention time.Duration    // 最近消息的保留时间，0 表示不保留
+	sendQueue       *SendQueueConfig // 为空时 Send* 直接调用 wxhelper 接口
+}
+
+// optionsFromEnv 环境变量作为默认配置
+func optionsFromEnv() clientOptions {
+	return clientOptions{
+		listenAddr:      env.Name(ENVTcpAddr).StringOrElse(DefaultTcpAddr),           // "19099"
+		apiBaseURL:      env.Name(ENVWxApiBaseUrl).StringOrElse(DefaultWxApiBaseUrl), // "http://127.0.0.1:19088"
+		hookURL:         env.Name(ENVTcpHookURL).StringOrElse(DefaultTcpHookURL),     // "127.0.0.1:19089"
+		hookMode:        env.Name(ENVHookMode).StringOrElse(HookModeTCP),
+		httpHookURL:     env.Name(ENVHttpHookURL).StringOrElse(""),
+		hookTimeout:     time.Duration(env.Name(ENVHookTimeout).IntOrElse(int(DefaultHTTPHookTimeout/time.Second))) * time.Second,
+		timeout:         DefaultAPITimeout,
+		retry:           DefaultRetryPolicy,
+		cacheDir:        utils.TempDir(),
+		bufferSize:      DefaultBufferSize,
+		loginPolicy:     DefaultLoginPolicy,
+		contactRefresh:  DefaultContactRefresh,
+		chatRoomTTL:     DefaultChatRoomTTL,
+		revokeRetention: DefaultRevokeRetention,
+	}
+}
+
+// WithListenAddr 本地接收消息的监听端口，如 "19099"
+func WithListenAddr(addr string) Option {
+	return func(o *clientOptions) { o.listenAddr = addr }
+}
+
+// WithAPIBaseURL wxhelper http api 地址，如 "http://127.0.0.1:19088"
+func WithAPIBaseURL(baseURL string) Option {
+	return func(o *clientOptions) { o.apiBaseURL = baseURL }
+}
+
+// WithHookURL tcp 模式下 wxhelper 推送消息的地址（host:port，需从 wxhelper 所在机器可访问）
+func WithHookURL(hookURL string) Option {
+	return func(o *clientOptions) { o.hookURL = hookURL }
+}
+
+// WithHTTPHook 使用 http 方式接收消息 <callbackURL: wxhelper 推送消息的地址>, <timeout: 推送超时>
+func WithHTTPHook(callbackURL string, timeout time.Duration) Option {
+	return func(o *clientOptions) {
+		o.hookMode = HookModeHTTP
+		o.httpHookURL = callbackURL
+		o.hookTimeout = timeout
+	}
+}
+
+// WithHTTPClient 请求 wxhelper api 使用的 http.Client
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *clientOptions) { o.httpClient = httpClient }
+}
+
+// WithTimeout 单次调用 wxhelper 接口的超时（不含重试），0 表示不限制，默认 DefaultAPITimeout
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *clientOptions) { o.timeout = timeout }
+}
+
+// WithRetryPolicy 调用 wxhelper 接口失败时的重试策略，默认 DefaultRetryPolicy，MaxAttempts <= 1 表示不重试
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *clientOptions) { o.retry = policy }
+}
+
+// WithAPIObserver 每次调用 wxhelper 接口结束后回调，用于指标、追踪，回调中不应阻塞
+func WithAPIObserver(observer func(call APICall)) Option {
+	return func(o *clientOptions) { o.observer = observer }
+}
+
+// WithCacheDir 图片、文件等缓存的根目录
+func WithCacheDir(dir string) Option {
+	return func(o *clientOptions) { o.cacheDir = dir }
+}
+
+// WithCacheRetention 缓存文件保留时间，超过后由后台定期清理，0 表示不清理
+func WithCacheRetention(retention time.Duration) Option {
+	return func(o *clientOptions) { o.retention = retention }
+}
+
+// WithLogger 客户端使用的日志
+func WithLogger(logger zerolog.Logger) Option {
+	return func(o *clientOptions) { o.logger = logging.NewFieldLogger(logger) }
+}
+
+// WithBufferSize 消息缓冲区大小
+func WithBufferSize(size int) Option {
+	return func(o *clientOptions) { o.bufferSize = size }
+}
+
+// WithListener 自定义消息监听者，设置后忽略监听端口与 hook 方式
+func WithListener(listener MessageListener) Option {
+	return func(o *clientOptions) { o.listener = listener }
+}
+
+// WithLoginPolicy 登录检测策略
+func WithLoginPolicy(policy LoginPolicy) Option {
+	return func(o *clientOptions) { o.loginPolicy = policy }
+}
+
+// WithContactRefresh 联系人目录的定期刷新间隔，默认 DefaultContactRefresh，0 表示仅在登录时刷新
+func WithContactRefresh(interval time.Duration) Option {
+	return func(o *clientOptions) { o.contactRefresh = interval }
+}
+
+// WithChatRoomCacheTTL 群信息缓存有效期，默认 DefaultChatRoomTTL，0 表示仅在收到成员变动的系统消息时失效
+func WithChatRoomCacheTTL(ttl time.Duration) Option {
+	return func(o *clientOptions) { o.chatRoomTTL = ttl }
+}
+
+// WithRevokeRetention 最近消息的保留时间，撤回通知在此时间内可查到原消息，默认 DefaultRevokeRetention，0 表示不保留
+func WithRevokeRetention(retention time.Duration) Option {
+	return func(o *clientOptions) { o.revokeRetention = retention }
+}
+
+// WithSendQueue Send*、Reply* 经由发送队列按限流与随机间隔发送，调用方阻塞直到消息发出，关闭客户端时等待队列发送完毕
+func WithSendQueue(cfg SendQueueConfig) Option {
+	return func(o *clientOptions) { o.sendQueue = &cfg }
+}
+
+// ServeOption Client.Serve、Manager.Serve 的配置项
+type ServeOption func(o *serveOptions)
+
+type serveOptions struct {
+	concurrency int // 同时处理的消息数上限
+}
+
+func newServeOptions(opts []ServeOption) serveOptions {
+	o := serveOptions{concurrency: DefaultServeConcurrency}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.concurrency <= 0 {
+		o.concurrency = DefaultServeConcurrency
+	}
+	return o
+}
+
+// WithServeConcurrency 同时处理的消息数上限，默认 DefaultServeConcurrency，为 1 时按顺序逐条处理
+func WithServeConcurrency(n int) ServeOption {
+	return func(o *serveOptions) { o.concurrency = n }
+}
+
+// newListener 根据配置创建监听者，http 配置有误时返回的监听者在 ListenAndServe、RegisterHook 时返回 ErrInvalidHookConfig
+func (o *clientOptions) newListener(log *logging.FieldLogger) MessageListener {
+	if o.listener != nil {
+		return o.listener
+	}
+	if o.hookMode != HookModeHTTP {
+		return NewTCPMessageListener(o.listenAddr)
+	}
+	callbackURL := o.httpHookURL
+	if callbackURL == "" {
+		callbackURL = "http://127.0.0.1:" + o.listenAddr + "/"
+	}
+	listener, err := NewHTTPMessageListener(":"+o.listenAddr, callbackURL, o.hookTimeout)
+	if err != nil {
+		err = fmt.Errorf("%w: %w", ErrInvalidHookConfig, err)
+		log.ErrorWithErr(err, "invalid http hook config")
+		return &invalidListener{err: err}
+	}
+	return listener
+}
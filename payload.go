@@ -0,0 +1,212 @@
+// Package wxhelper_sdk
+// @Author Clover
+// @Data 2025/1/13 上午10:30:00
+// @Desc 不同类型消息内容的解析
+package wxhelper_sdk
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrMsgTypeMismatch = errors.New("message type mismatch")
+	ErrPayloadDecode   = errors.New("decode message payload failed")
+)
+
+// Location 位置消息
+type Location struct {
+	X       float64 `xml:"x,attr"` // 纬度
+	Y       float64 `xml:"y,attr"` // 经度
+	Scale   int     `xml:"scale,attr"`
+	Label   string  `xml:"label,attr"`
+	PoiName string  `xml:"poiname,attr"`
+	PoiID   string  `xml:"poiid,attr"`
+}
+
+// Voice 语音消息
+type Voice struct {
+	Length      int    `xml:"length,attr"`
+	VoiceLength int    `xml:"voicelength,attr"` // 时长（毫秒）
+	FromUser    string `xml:"fromusername,attr"`
+	BufID       string `xml:"bufid,attr"`
+}
+
+// Video 视频消息
+type Video struct {
+	AesKey      string `xml:"aeskey,attr"`
+	CdnThumbURL string `xml:"cdnthumburl,attr"`
+	CdnVideoURL string `xml:"cdnvideourl,attr"`
+	Length      int    `xml:"length,attr"`
+	PlayLength  int    `xml:"playlength,attr"` // 时长（秒）
+	MD5         string `xml:"md5,attr"`
+	FromUser    string `xml:"fromusername,attr"`
+}
+
+// Emoji 表情消息
+type Emoji struct {
+	FromUser string `xml:"fromusername,attr"`
+	ToUser   string `xml:"tousername,attr"`
+	MD5      string `xml:"md5,attr"`
+	Len      int    `xml:"len,attr"`
+	CdnURL   string `xml:"cdnurl,attr"`
+	Width    int    `xml:"width,attr"`
+	Height   int    `xml:"height,attr"`
+}
+
+// Card 名片消息
+type Card struct {
+	Username        string `xml:"username,attr"`
+	Nickname        string `xml:"nickname,attr"`
+	Alias           string `xml:"alias,attr"`
+	Province        string `xml:"province,attr"`
+	City            string `xml:"city,attr"`
+	Sex             int    `xml:"sex,attr"`
+	CertFlag        int    `xml:"certflag,attr"` // 非0为公众号
+	BigHeadImgURL   string `xml:"bigheadimgurl,attr"`
+	SmallHeadImgURL string `xml:"smallheadimgurl,attr"`
+}
+
+// FriendRequest 好友请求
+type FriendRequest struct {
+	FromUser        string `xml:"fromusername,attr"`
+	FromNickname    string `xml:"fromnickname,attr"`
+	EncryptUsername string `xml:"encryptusername,attr"` // v3
+	Ticket          string `xml:"ticket,attr"`          // v4
+	Content         string `xml:"content,attr"`         // 验证信息
+	Scene           int    `xml:"scene,attr"`
+	Sex             int    `xml:"sex,attr"`
+	Alias           string `xml:"alias,attr"`
+	Sign            string `xml:"sign,attr"`
+	BigHeadImgURL   string `xml:"bigheadimgurl,attr"`
+	SmallHeadImgURL string `xml:"smallheadimgurl,attr"`
+}
+
+// msgEnvelope 各类 XML 消息的外层 <msg>
+type msgEnvelope struct {
+	XMLName  xml.Name  `xml:"msg"`
+	Location *Location `xml:"location"`
+	Voice    *Voice    `xml:"voicemsg"`
+	Video    *Video    `xml:"videomsg"`
+	Emoji    *Emoji    `xml:"emoji"`
+}
+
+// xmlPayload 消息中的 XML 内容，群消息已去除发送者前缀
+func (m *Message) xmlPayload() string {
+	return strings.TrimSpace(m.Text())
+}
+
+func (m *Message) expectType(types ...MsgType) error {
+	for _, t := range types {
+		if m.Type == t {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: got %s, want %s", ErrMsgTypeMismatch, m.Type, types[0])
+}
+
+func (m *Message) decodeEnvelope(v any) error {
+	if err := xml.Unmarshal([]byte(m.xmlPayload()), v); err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrPayloadDecode, m.Type, err)
+	}
+	return nil
+}
+
+// AsText 文本消息内容（群消息已去除发送者前缀）
+func (m *Message) AsText() (string, error) {
+	if err := m.expectType(MsgTypeText); err != nil {
+		return "", err
+	}
+	return m.Text(), nil
+}
+
+// AsLocation 解析位置消息
+func (m *Message) AsLocation() (*Location, error) {
+	if err := m.expectType(MsgTypeLocation); err != nil {
+		return nil, err
+	}
+	var env msgEnvelope
+	if err := m.decodeEnvelope(&env); err != nil {
+		return nil, err
+	}
+	if env.Location == nil {
+		return nil, fmt.Errorf("%w: missing <location>", ErrPayloadDecode)
+	}
+	return env.Location, nil
+}
+
+// AsVoice 解析语音消息
+func (m *Message) AsVoice() (*Voice, error) {
+	if err := m.expectType(MsgTypeVoice); err != nil {
+		return nil, err
+	}
+	var env msgEnvelope
+	if err := m.decodeEnvelope(&env); err != nil {
+		return nil, err
+	}
+	if env.Voice == nil {
+		return nil, fmt.Errorf("%w: missing <voicemsg>", ErrPayloadDecode)
+	}
+	return env.Voice, nil
+}
+
+// AsVideo 解析视频消息
+func (m *Message) AsVideo() (*Video, error) {
+	if err := m.expectType(MsgTypeVideo, MsgTypeMicroVideo); err != nil {
+		return nil, err
+	}
+	var env msgEnvelope
+	if err := m.decodeEnvelope(&env); err != nil {
+		return nil, err
+	}
+	if env.Video == nil {
+		return nil, fmt.Errorf("%w: missing <videomsg>", ErrPayloadDecode)
+	}
+	return env.Video, nil
+}
+
+// AsEmoji 解析表情消息
+func (m *Message) AsEmoji() (*Emoji, error) {
+	if err := m.expectType(MsgTypeEmoji); err != nil {
+		return nil, err
+	}
+	var env msgEnvelope
+	if err := m.decodeEnvelope(&env); err != nil {
+		return nil, err
+	}
+	if env.Emoji == nil {
+		return nil, fmt.Errorf("%w: missing <emoji>", ErrPayloadDecode)
+	}
+	return env.Emoji, nil
+}
+
+// AsCard 解析名片消息
+func (m *Message) AsCard() (*Card, error) {
+	if err := m.expectType(MsgTypeCard); err != nil {
+		return nil, err
+	}
+	var card Card
+	if err := m.decodeEnvelope(&card); err != nil {
+		return nil, err
+	}
+	return &card, nil
+}
+
+// AsFriendRequest 解析好友请求
+func (m *Message) AsFriendRequest() (*FriendRequest, error) {
+	if err := m.expectType(MsgTypeFriendVerify); err != nil {
+		return nil, err
+	}
+	var req FriendRequest
+	if err := m.decodeEnvelope(&req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// AsAppMsg 解析应用消息，等同于 AppMsg
+func (m *Message) AsAppMsg() (*AppMsg, error) {
+	return m.AppMsg()
+}
@@ -0,0 +1,57 @@
+package wxhelper_sdk
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMsgType_String(t *testing.T) {
+	assert.Equal(t, "Text", MsgTypeText.String())
+	assert.Equal(t, "Recalled", MsgTypeRecalled.String())
+	assert.Equal(t, "MsgType(999)", MsgType(999).String())
+	assert.False(t, MsgType(999).Known())
+}
+
+func TestMessage_AsText(t *testing.T) {
+	msg := &Message{Type: MsgTypeText, FromUser: "123@chatroom", Content: "wxid_a:\nhello"}
+	text, err := msg.AsText()
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", text)
+
+	_, err = (&Message{Type: MsgTypeImage}).AsText()
+	assert.True(t, errors.Is(err, ErrMsgTypeMismatch))
+}
+
+func TestMessage_AsLocation(t *testing.T) {
+	msg := &Message{
+		Type:     MsgTypeLocation,
+		FromUser: "123@chatroom",
+		Content: "wxid_a:\n<?xml version=\"1.0\"?>\n<msg>\n\t<location x=\"22.543096\" y=\"114.057865\" scale=\"15\" " +
+			"label=\"广东省深圳市福田区\" maptype=\"roadmap\" poiname=\"市民中心\" poiid=\"\" />\n</msg>\n",
+	}
+	loc, err := msg.AsLocation()
+	assert.Nil(t, err)
+	assert.Equal(t, 22.543096, loc.X)
+	assert.Equal(t, 114.057865, loc.Y)
+	assert.Equal(t, 15, loc.Scale)
+	assert.Equal(t, "市民中心", loc.PoiName)
+}
+
+func TestMessage_AsCard(t *testing.T) {
+	msg := &Message{
+		Type:     MsgTypeCard,
+		FromUser: "wxid_a",
+		Content:  `<?xml version="1.0"?><msg bigheadimgurl="" smallheadimgurl="" username="wxid_card" nickname="Alice" alias="alice01" province="Guangdong" city="Shenzhen" sex="2" certflag="0" />`,
+	}
+	card, err := msg.AsCard()
+	assert.Nil(t, err)
+	assert.Equal(t, "wxid_card", card.Username)
+	assert.Equal(t, "Alice", card.Nickname)
+	assert.Equal(t, 2, card.Sex)
+}
+
+func TestMessage_AsAppMsg_Malformed(t *testing.T) {
+	_, err := (&Message{Type: MsgTypeApp, Content: "<msg><appmsg>"}).AsAppMsg()
+	assert.True(t, errors.Is(err, ErrPayloadDecode))
+}
@@ -0,0 +1,144 @@
+// Package wxhelper_sdk
+// @Author Clover
+// @Data 2025/1/12 下午8:40:00
+// @Desc 令牌桶限流
+package wxhelper_sdk
+
+import (
+	"sync"
+	"time"
+)
+
+// tokenBucket 令牌桶 <rate: 每秒生成令牌数>, <burst: 桶容量>
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// refill 按经过的时间补充令牌，调用方需持有锁
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	b.last = now
+	b.tokens += elapsed * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+// allow 尝试取出一个令牌
+func (b *tokenBucket) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	if b.tokens >= 1 {
+		b.tokens--
+		return true
+	}
+	return false
+}
+
+// delay 距离下一个令牌可用的时长，不取出令牌
+func (b *tokenBucket) delay() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	if b.tokens >= 1 || b.rate <= 0 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// reserve 预定一个令牌，返回需要等待的时长
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	b.tokens--
+	if b.tokens >= 0 || b.rate <= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// idle 令牌桶补满后是否又经过了 window，此时丢弃与重新创建等价
+func (b *tokenBucket) idle(now time.Time, window time.Duration) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rate <= 0 {
+		return false
+	}
+	fullAt := b.last.Add(time.Duration((b.burst - b.tokens) / b.rate * float64(time.Second)))
+	return now.Sub(fullAt) > window
+}
+
+// keyedLimiter 按 key 区分的令牌桶集合，补满后闲置超过 burst/rate 的令牌桶会被回收
+type keyedLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   int
+	window  time.Duration // 令牌桶补满后的闲置回收时长，0 表示不回收
+	swept   time.Time
+	buckets map[string]*tokenBucket
+}
+
+func newKeyedLimiter(rate float64, burst int) *keyedLimiter {
+	l := &keyedLimiter{rate: rate, burst: max(burst, 1), swept: time.Now(), buckets: make(map[string]*tokenBucket)}
+	if rate > 0 {
+		l.window = time.Duration(float64(l.burst) / rate * float64(time.Second))
+	}
+	return l
+}
+
+func (l *keyedLimiter) get(key string) *tokenBucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now := time.Now(); l.window > 0 && now.Sub(l.swept) >= l.window {
+		l.sweep(now)
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = newTokenBucket(l.rate, l.burst)
+		l.buckets[key] = b
+	}
+	return b
+}
+
+// sweep 回收闲置的令牌桶，调用方需持有锁
+func (l *keyedLimiter) sweep(now time.Time) {
+	l.swept = now
+	for key, b := range l.buckets {
+		if b.idle(now, l.window) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// len 当前保留的令牌桶数量
+func (l *keyedLimiter) len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
+
+func (l *keyedLimiter) allow(key string) bool {
+	return l.get(key).allow()
+}
+
+func (l *keyedLimiter) reserve(key string) time.Duration {
+	return l.get(key).reserve()
+}
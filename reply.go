@@ -0,0 +1,71 @@
+// Package wxhelper_sdk
+// @Author Clover
+// @Data 2025/1/12 下午4:05:00
+// @Desc 消息快捷回复
+package wxhelper_sdk
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+const chatRoomSuffix = "@chatroom"
+
+var (
+	ErrNoClient = errors.New("message is not bound to a client")
+)
+
+// isChatRoomID 是否为群聊id
+func isChatRoomID(id string) bool {
+	return strings.HasSuffix(id, chatRoomSuffix)
+}
+
+// conversationID 回复的目标会话：群消息回复到群，私聊回复给对方
+func (m *Message) conversationID() string {
+	if m.IsGroup() {
+		return m.ChatRoomID()
+	}
+	// 自己发出的消息，对方为 ToUser
+	if m.account != nil && m.FromUser == m.account.Wxid {
+		return m.ToUser
+	}
+	return m.FromUser
+}
+
+// Reply 回复文本消息到该消息所在的会话
+func (m *Message) Reply(ctx context.Context, text string) error {
+	if m.client == nil {
+		return ErrNoClient
+	}
+	return m.client.SendText(ctx, m.conversationID(), text)
+}
+
+// ReplyImage 回复图片到该消息所在的会话
+func (m *Message) ReplyImage(ctx context.Context, imgPath string) error {
+	if m.client == nil {
+		return ErrNoClient
+	}
+	return m.client.SendImage(ctx, m.conversationID(), imgPath)
+}
+
+// ReplyFile 回复文件到该消息所在的会话
+func (m *Message) ReplyFile(ctx context.Context, filePath string) error {
+	if m.client == nil {
+		return ErrNoClient
+	}
+	return m.client.SendFile(ctx, m.conversationID(), filePath)
+}
+
+// ReplyAt 群消息中@发送者并回复，私聊消息等同于 Reply
+func (m *Message) ReplyAt(ctx context.Context, text string) error {
+	if m.client == nil {
+		return ErrNoClient
+	}
+	to := m.conversationID()
+	sender := m.Sender()
+	if !m.IsGroup() || sender == "" {
+		return m.client.SendText(ctx, to, text)
+	}
+	return m.client.SendAtText(ctx, to, []string{sender}, text)
+}
@@ -0,0 +1,53 @@
+package wxhelper_sdk
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"wxhelper-sdk/inner"
+)
+
+func TestMessage_ConversationID(t *testing.T) {
+	bot := &Account{Wxid: "wxid_bot"}
+	tests := []struct {
+		name string
+		msg  *Message
+		want string
+	}{
+		{"group", &Message{FromUser: "123@chatroom", ToUser: "wxid_bot", Content: "wxid_a:\nhi", account: bot}, "123@chatroom"},
+		{"group sent by self", &Message{FromUser: "wxid_bot", ToUser: "123@chatroom", Content: "hi", account: bot}, "123@chatroom"},
+		{"private", &Message{FromUser: "wxid_a", ToUser: "wxid_bot", Content: "hi", account: bot}, "wxid_a"},
+		{"private sent by self", &Message{FromUser: "wxid_bot", ToUser: "wxid_a", Content: "hi", account: bot}, "wxid_a"},
+		{"private without account", &Message{FromUser: "wxid_a", ToUser: "wxid_bot", Content: "hi"}, "wxid_a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.msg.conversationID())
+		})
+	}
+}
+
+func TestMessage_Reply(t *testing.T) {
+	fake := &endpointServer{}
+	client := newTestClient(t, fake)
+	bot := &Account{Wxid: "wxid_bot"}
+	ctx := context.Background()
+
+	assert.Equal(t, ErrNoClient, (&Message{FromUser: "wxid_a"}).Reply(ctx, "hi"))
+
+	// 自己发出的私聊消息回复给对方
+	msg := &Message{Type: MsgTypeText, FromUser: "wxid_bot", ToUser: "wxid_a", Content: "hi", account: bot, client: client}
+	assert.Nil(t, msg.Reply(ctx, "ok"))
+	assert.Equal(t, inner.EndpointSendText, fake.path)
+	assert.Equal(t, map[string]any{"wxid": "wxid_a", "msg": "ok"}, fake.body)
+
+	// 私聊 ReplyAt 等同于 Reply
+	assert.Nil(t, msg.ReplyAt(ctx, "ok"))
+	assert.Equal(t, inner.EndpointSendText, fake.path)
+
+	// 群消息回复到群并@发送者
+	msg = &Message{Type: MsgTypeText, FromUser: "123@chatroom", ToUser: "wxid_bot", Content: "wxid_a:\nhi", account: bot, client: client}
+	assert.Nil(t, msg.ReplyAt(ctx, "ok"))
+	assert.Equal(t, inner.EndpointSendAtText, fake.path)
+	assert.Equal(t, map[string]any{"chatRoomId": "123@chatroom", "wxids": "wxid_a", "msg": "ok"}, fake.body)
+}
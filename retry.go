@@ -0,0 +1,26 @@
+// Package wxhelper_sdk
+// @Author Clover
+// @Data 2025/1/19 上午11:20:00
+// @Desc 接口调用的超时与重试
+package wxhelper_sdk
+
+import (
+	"context"
+	"wxhelper-sdk/inner"
+)
+
+// RetryPolicy 调用 wxhelper 接口失败（网络错误、超时、5xx）时的重试策略，
+// 默认只重试查询类等幂等接口，发送消息需通过 AllowRetry 显式允许
+type RetryPolicy = inner.RetryPolicy
+
+var DefaultRetryPolicy = inner.DefaultRetryPolicy
+
+// DefaultAPITimeout 单次调用 wxhelper 接口的默认超时
+const DefaultAPITimeout = inner.DefaultTimeout
+
+// AllowRetry 允许该 ctx 下的发送等非幂等调用按重试策略重试，wxhelper 已处理但响应丢失时会重复发送
+//
+//	err := client.SendText(wxhelper_sdk.AllowRetry(ctx), to, content)
+func AllowRetry(ctx context.Context) context.Context {
+	return inner.WithRetry(ctx)
+}
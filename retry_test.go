@@ -0,0 +1,101 @@
+package wxhelper_sdk
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	var requests, failures atomic.Int32
+	var delay atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		time.Sleep(time.Duration(delay.Load()))
+		if failures.Add(-1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":1,"msg":"success"}`))
+	}))
+	defer server.Close()
+	var attempts []int
+	client := NewClient(
+		WithAPIBaseURL(server.URL),
+		WithCacheDir(t.TempDir()),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond, Multiplier: 2, Jitter: 0.5}),
+		WithAPIObserver(func(call APICall) { attempts = append(attempts, call.Attempt) }),
+	)
+	defer client.stop()
+	client.state.Store(int32(StateLoggedIn))
+	ctx := context.Background()
+	reset := func(n int32) {
+		requests.Store(0)
+		failures.Store(n)
+		attempts = nil
+	}
+
+	// 幂等接口失败后重试
+	reset(2)
+	ok, err := client.wxClient.CheckLogin(ctx)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int32(3), requests.Load())
+	assert.Equal(t, []int{1, 2, 3}, attempts)
+
+	reset(3)
+	_, err = client.wxClient.CheckLogin(ctx)
+	assert.True(t, errors.Is(err, ErrUnexpectedStatus))
+	assert.Equal(t, int32(3), requests.Load())
+
+	// 查询群成员、联系人资料等只读接口同样重试
+	reset(1)
+	_, err = client.wxClient.GetMemberFromChatRoom(ctx, "123@chatroom")
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), requests.Load())
+	reset(1)
+	_, err = client.wxClient.GetContactProfile(ctx, "wxid_a")
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), requests.Load())
+
+	// 发送消息默认不重试
+	reset(1)
+	assert.True(t, errors.Is(client.SendImage(ctx, "wxid_a", `C:\a.png`), ErrUnexpectedStatus))
+	assert.Equal(t, int32(1), requests.Load())
+
+	// 改变群成员等状态的接口同样不重试
+	reset(1)
+	err = client.wxClient.DelMemberFromChatRoom(ctx, "123@chatroom", []string{"wxid_a"})
+	assert.True(t, errors.Is(err, ErrUnexpectedStatus))
+	assert.Equal(t, int32(1), requests.Load())
+	assert.Equal(t, []int{1}, attempts)
+
+	// 显式允许后重试
+	reset(1)
+	assert.Nil(t, client.SendImage(AllowRetry(ctx), "wxid_a", `C:\a.png`))
+	assert.Equal(t, int32(2), requests.Load())
+
+	// 单次调用超时
+	reset(0)
+	delay.Store(int64(200 * time.Millisecond))
+	client.wxClient.SetTimeout(20 * time.Millisecond)
+	start := time.Now()
+	err = client.SendImage(ctx, "wxid_a", `C:\a.png`)
+	assert.True(t, errors.Is(err, ErrRequest))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Less(t, time.Since(start), 150*time.Millisecond)
+
+	// ctx 结束后不再重试
+	reset(0)
+	client.wxClient.SetTimeout(0)
+	cancelCtx, cancel := context.WithTimeout(ctx, 30*time.Millisecond)
+	defer cancel()
+	_, err = client.wxClient.CheckLogin(cancelCtx)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, int32(1), requests.Load())
+}
@@ -0,0 +1,172 @@
+// Package wxhelper_sdk
+// @Author Clover
+// @Data 2025/1/23 上午11:00:00
+// @Desc 消息撤回：最近消息存储与撤回事件
+package wxhelper_sdk
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultRevokeRetention = 5 * time.Minute // 微信仅允许撤回 2 分钟内的消息，留出余量
+	maxRecentMessages      = 10000           // 最近消息存储的数量上限
+)
+
+// Revoke 撤回通知
+type Revoke struct {
+	Session    string   // 撤回消息所在的会话：好友 wxid 或群 id
+	MsgId      int64    // 被撤回消息的 MsgId（sysmsg 中的 newmsgid）
+	ReplaceMsg string   // 撤回提示，如 "张三" 撤回了一条消息
+	Original   *Message // 被撤回的原消息（不含 Base64Img），不在最近消息存储中（超过保留时间或未收到）时为 nil
+	Message    *Message // 撤回通知本身
+}
+
+// revokeMsg 撤回通知 <sysmsg type="revokemsg">
+type revokeMsg struct {
+	Type   string `xml:"type,attr"`
+	Revoke *struct {
+		Session    string `xml:"session"`
+		NewMsgId   string `xml:"newmsgid"`
+		ReplaceMsg string `xml:"replacemsg"`
+	} `xml:"revokemsg"`
+}
+
+// AsRevoke 解析撤回通知，Original 需通过 OnRevoke 或 Client.RecentMessage 获取
+func (m *Message) AsRevoke() (*Revoke, error) {
+	if err := m.expectType(MsgTypeRecalled); err != nil {
+		return nil, err
+	}
+	var env revokeMsg
+	if err := m.decodeEnvelope(&env); err != nil {
+		return nil, err
+	}
+	if env.Type != "revokemsg" || env.Revoke == nil {
+		return nil, fmt.Errorf("%w: missing <revokemsg>", ErrPayloadDecode)
+	}
+	msgID, err := strconv.ParseInt(strings.TrimSpace(env.Revoke.NewMsgId), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: newmsgid: %w", ErrPayloadDecode, err)
+	}
+	return &Revoke{
+		Session:    strings.TrimSpace(env.Revoke.Session),
+		MsgId:      msgID,
+		ReplaceMsg: strings.TrimSpace(env.Revoke.ReplaceMsg),
+		Message:    m,
+	}, nil
+}
+
+// isRevoke 是否可能为撤回通知，避免对每条消息解析 XML
+func (m *Message) isRevoke() bool {
+	return m.Type == MsgTypeRecalled && strings.Contains(m.Content, "revokemsg")
+}
+
+type recentEntry struct {
+	msg        *Message
+	receivedAt time.Time
+}
+
+// messageStore 最近收到的消息，按 MsgId 查找，超过保留时间或数量上限时按接收顺序淘汰
+type messageStore struct {
+	mu        sync.Mutex
+	retention time.Duration // 0 表示不存储
+	limit     int
+	byID      map[int64]recentEntry
+	order     []int64 // 按接收顺序排列的 MsgId
+}
+
+func newMessageStore(retention time.Duration, limit int) *messageStore {
+	return &messageStore{retention: retention, limit: limit, byID: make(map[int64]recentEntry)}
+}
+
+func (s *messageStore) put(m *Message, now time.Time) {
+	if s.retention <= 0 || m.MsgId == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.byID[m.MsgId]; ok { // 重复推送的消息
+		return
+	}
+	stored := *m
+	stored.Base64Img = "" // 图片可达数十 MB，已保存到缓存目录（FileInfo），不随消息保留
+	s.order = append(s.order, m.MsgId)
+	s.byID[m.MsgId] = recentEntry{msg: &stored, receivedAt: now}
+	s.evict(now)
+}
+
+// evict 淘汰过期及超出数量上限的消息，调用方需持有锁
+func (s *messageStore) evict(now time.Time) {
+	n := 0
+	for ; n < len(s.order); n++ {
+		entry := s.byID[s.order[n]]
+		if len(s.order)-n <= s.limit && now.Sub(entry.receivedAt) <= s.retention {
+			break
+		}
+		delete(s.byID, s.order[n])
+	}
+	if n > 0 {
+		s.order = append(s.order[:0], s.order[n:]...)
+	}
+}
+
+func (s *messageStore) get(msgID int64, now time.Time) (*Message, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.byID[msgID]
+	if !ok || now.Sub(entry.receivedAt) > s.retention {
+		return nil, false
+	}
+	return entry.msg, true
+}
+
+func (s *messageStore) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.byID)
+}
+
+// RecentMessage 按 MsgId 查找保留时间内收到的消息，返回的是副本，图片消息不含 Base64Img（可通过 FileInfo 读取）
+func (c *Client) RecentMessage(msgID int64) (*Message, bool) {
+	return c.recent.get(msgID, time.Now())
+}
+
+// revokeHooks 撤回事件订阅
+type revokeHooks struct {
+	mu  sync.RWMutex
+	fns []func(revoke *Revoke)
+}
+
+// OnRevoke 订阅消息撤回，收到撤回通知时（放入消息缓冲区之前）同步调用，回调中不应阻塞
+// 原消息需在 WithRevokeRetention 设置的保留时间内收到，否则 Revoke.Original 为 nil
+func (c *Client) OnRevoke(fn func(revoke *Revoke)) {
+	c.revokes.mu.Lock()
+	defer c.revokes.mu.Unlock()
+	c.revokes.fns = append(c.revokes.fns, fn)
+}
+
+// handleRevoke 撤回通知查找原消息并通知订阅者，其余消息存入最近消息存储
+func (c *Client) handleRevoke(m *Message) {
+	now := time.Now()
+	if !m.isRevoke() {
+		c.recent.put(m, now)
+		return
+	}
+	revoke, err := m.AsRevoke()
+	if err != nil {
+		c.log.WarnWithErr(err, "decode revoke message failed", map[string]interface{}{"msgId": m.MsgId})
+		return
+	}
+	revoke.Original, _ = c.recent.get(revoke.MsgId, now)
+	c.log.Debug("message revoked", map[string]interface{}{"session": revoke.Session, "msgId": revoke.MsgId, "found": revoke.Original != nil})
+	c.revokes.mu.RLock()
+	fns := c.revokes.fns
+	c.revokes.mu.RUnlock()
+	for _, fn := range fns {
+		fn(revoke)
+	}
+}
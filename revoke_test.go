@@ -0,0 +1,109 @@
+package wxhelper_sdk
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func revokeMessage(session, content string) *Message {
+	return &Message{MsgId: 2, FromUser: session, ToUser: "wxid_bot", Type: MsgTypeRecalled, Content: content}
+}
+
+func TestMessage_AsRevoke(t *testing.T) {
+	msg := revokeMessage("wxid_zs", fmtRevoke("wxid_zs"))
+	revoke, err := msg.AsRevoke()
+	if assert.Nil(t, err) {
+		assert.Equal(t, "wxid_zs", revoke.Session)
+		assert.Equal(t, int64(8736275329416152101), revoke.MsgId)
+		assert.Equal(t, `"张三" 撤回了一条消息`, revoke.ReplaceMsg)
+		assert.Same(t, msg, revoke.Message)
+	}
+
+	// 群消息带有群 id 前缀
+	revoke, err = revokeMessage("123@chatroom", "123@chatroom:\n"+fmtRevoke("123@chatroom")).AsRevoke()
+	if assert.Nil(t, err) {
+		assert.Equal(t, "123@chatroom", revoke.Session)
+	}
+
+	_, err = revokeMessage("wxid_zs", `<sysmsg type="pat"><pat></pat></sysmsg>`).AsRevoke()
+	assert.True(t, errors.Is(err, ErrPayloadDecode))
+	_, err = (&Message{Type: MsgTypeText}).AsRevoke()
+	assert.True(t, errors.Is(err, ErrMsgTypeMismatch))
+}
+
+func fmtRevoke(session string) string {
+	return `<sysmsg type="revokemsg"><revokemsg><session>` + session + `</session><oldmsgid>1057093040</oldmsgid>` +
+		`<newmsgid>8736275329416152101</newmsgid><replacemsg><![CDATA["张三" 撤回了一条消息]]></replacemsg></revokemsg></sysmsg>`
+}
+
+func TestMessageStore(t *testing.T) {
+	now := time.Now()
+	store := newMessageStore(time.Minute, 3)
+	for i := int64(1); i <= 3; i++ {
+		store.put(&Message{MsgId: i}, now.Add(time.Duration(i)*time.Second))
+	}
+	store.put(&Message{MsgId: 0}, now) // 无 MsgId 不存储
+	assert.Equal(t, 3, store.len())
+
+	// 超出数量上限时淘汰最早的消息
+	store.put(&Message{MsgId: 4}, now.Add(4*time.Second))
+	_, ok := store.get(1, now.Add(4*time.Second))
+	assert.False(t, ok)
+	msg, ok := store.get(2, now.Add(4*time.Second))
+	if assert.True(t, ok) {
+		assert.Equal(t, int64(2), msg.MsgId)
+	}
+
+	// 超过保留时间后查不到，并在下次写入时淘汰
+	_, ok = store.get(2, now.Add(2*time.Minute))
+	assert.False(t, ok)
+	store.put(&Message{MsgId: 5}, now.Add(2*time.Minute))
+	assert.Equal(t, 1, store.len())
+
+	disabled := newMessageStore(0, 3)
+	disabled.put(&Message{MsgId: 1}, now)
+	assert.Equal(t, 0, disabled.len())
+}
+
+func TestClient_OnRevoke(t *testing.T) {
+	client := NewClient(WithCacheDir(t.TempDir()))
+	t.Cleanup(client.stop)
+	var revokes []*Revoke
+	client.OnRevoke(func(revoke *Revoke) { revokes = append(revokes, revoke) })
+
+	original := &Message{MsgId: 8736275329416152101, FromUser: "wxid_zs", Type: MsgTypeText, Content: "hello"}
+	client.handleRevoke(original)
+	found, ok := client.RecentMessage(original.MsgId)
+	assert.True(t, ok)
+	assert.Equal(t, original, found)
+
+	client.handleRevoke(revokeMessage("wxid_zs", fmtRevoke("wxid_zs")))
+	if assert.Len(t, revokes, 1) {
+		assert.Equal(t, original, revokes[0].Original)
+	}
+
+	// 图片消息不保留 base64 数据
+	img := &Message{MsgId: 3, FromUser: "wxid_zs", Type: MsgTypeImage, Base64Img: "aGVsbG8="}
+	client.handleRevoke(img)
+	found, ok = client.RecentMessage(img.MsgId)
+	if assert.True(t, ok) {
+		assert.Empty(t, found.Base64Img)
+		assert.Equal(t, MsgTypeImage, found.Type)
+	}
+	assert.Equal(t, "aGVsbG8=", img.Base64Img)
+	_, ok = client.RecentMessage(2) // 撤回通知本身不存储
+	assert.False(t, ok)
+
+	// 原消息未收到时 Original 为空
+	other := NewClient(WithCacheDir(t.TempDir()), WithRevokeRetention(0))
+	t.Cleanup(other.stop)
+	other.OnRevoke(func(revoke *Revoke) { revokes = append(revokes, revoke) })
+	other.handleRevoke(original)
+	other.handleRevoke(revokeMessage("wxid_zs", fmtRevoke("wxid_zs")))
+	if assert.Len(t, revokes, 2) {
+		assert.Nil(t, revokes[1].Original)
+		assert.Equal(t, int64(8736275329416152101), revokes[1].MsgId)
+	}
+}
@@ -0,0 +1,142 @@
+// Package wxhelper_sdk
+// @Author Clover
+// @Data 2025/1/12 下午3:20:00
+// @Desc 消息发送
+package wxhelper_sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"wxhelper-sdk/inner"
+)
+
+var (
+	ErrSendFailed    = errors.New("send message failed")
+	ErrEmptyReceiver = errors.New("receiver is empty")
+	ErrEmptyContent  = errors.New("content is empty")
+)
+
+// SendError 发送失败时返回的错误，可通过 errors.As 获取失败的操作与接收者
+type SendError struct {
+	Op  string // 操作：text/image/file/atText/forward/pat/emotion
+	To  string // 接收者 wxid 或 chatroom id
+	Err error
+}
+
+func (e *SendError) Error() string {
+	return fmt.Sprintf("send %s to %s failed: %v", e.Op, e.To, e.Err)
+}
+
+func (e *SendError) Unwrap() []error {
+	return []error{ErrSendFailed, e.Err}
+}
+
+// checkSend 发送前的通用校验
+func (c *Client) checkSend(to string) error {
+	switch c.State() {
+	case StateClosed:
+		return ErrClientClosed
+	case StateLoggedIn, StateClosing: // 关闭中仍可回复正在取走的消息
+	default:
+		return ErrNotLogin
+	}
+	if strings.TrimSpace(to) == "" {
+		return ErrEmptyReceiver
+	}
+	return nil
+}
+
+// checkMessage 校验待发送的消息
+func (c *Client) checkMessage(m OutboundMessage) error {
+	if err := c.checkSend(m.To); err != nil {
+		return err
+	}
+	if m.Content == "" {
+		return ErrEmptyContent
+	}
+	return nil
+}
+
+// submit 发送消息，配置了 WithSendQueue 时经由发送队列并等待发送结果
+func (c *Client) submit(ctx context.Context, m OutboundMessage) error {
+	if err := c.checkMessage(m); err != nil {
+		return err
+	}
+	if c.queue == nil {
+		return c.deliver(ctx, m)
+	}
+	result, err := c.queue.Enqueue(ctx, m)
+	if err != nil {
+		return err
+	}
+	return result.Wait(ctx)
+}
+
+// deliver 调用 wxhelper 接口发送消息，失败时返回 SendError
+func (c *Client) deliver(ctx context.Context, m OutboundMessage) error {
+	var err error
+	switch m.Op {
+	case "text":
+		err = c.wxClient.SendText(ctx, m.To, m.Content)
+	case "image":
+		err = c.wxClient.SendImage(ctx, m.To, m.Content)
+	case "file":
+		err = c.wxClient.SendFile(ctx, m.To, m.Content)
+	case "atText":
+		err = c.wxClient.SendAtText(ctx, inner.SendAtTextOption{WxIds: m.AtWxids, ChatRoomID: m.To, Content: m.Content})
+	case "pat":
+		err = c.wxClient.SendPatMsg(ctx, m.To, m.Content)
+	case "emotion":
+		err = c.wxClient.SendCustomEmotion(ctx, m.To, m.Content)
+	default:
+		err = errors.New("unknown send op")
+	}
+	if err != nil {
+		return &SendError{Op: m.Op, To: m.To, Err: err}
+	}
+	return nil
+}
+
+// SendText 发送文本消息 <to: 好友wxid或群id>
+func (c *Client) SendText(ctx context.Context, to string, content string) error {
+	return c.submit(ctx, TextMessage(to, content))
+}
+
+// SendImage 发送图片 <imgPath: wxhelper 所在机器上的图片路径>
+func (c *Client) SendImage(ctx context.Context, to string, imgPath string) error {
+	return c.submit(ctx, ImageMessage(to, imgPath))
+}
+
+// SendFile 发送文件 <filePath: wxhelper 所在机器上的文件路径>
+func (c *Client) SendFile(ctx context.Context, to string, filePath string) error {
+	return c.submit(ctx, FileMessage(to, filePath))
+}
+
+// SendAtText 在群聊中发送@消息 <wxids: 被@的成员，传入 "notify@all" 为@所有人>
+func (c *Client) SendAtText(ctx context.Context, chatRoomID string, wxids []string, content string) error {
+	return c.submit(ctx, AtTextMessage(chatRoomID, wxids, content))
+}
+
+// Forward 转发消息 <msgID: 原消息 MsgId>
+func (c *Client) Forward(ctx context.Context, to string, msgID int64) error {
+	if err := c.checkSend(to); err != nil {
+		return err
+	}
+	if err := c.wxClient.ForwardMsg(ctx, strconv.FormatInt(msgID, 10), to); err != nil {
+		return &SendError{Op: "forward", To: to, Err: err}
+	}
+	return nil
+}
+
+// SendPat 拍一拍 <to: 群 id 或好友 wxid>, <wxid: 被拍的 wxid>
+func (c *Client) SendPat(ctx context.Context, to string, wxid string) error {
+	return c.submit(ctx, PatMessage(to, wxid))
+}
+
+// SendEmotion 发送表情 <filePath: wxhelper 所在机器上的表情文件路径>
+func (c *Client) SendEmotion(ctx context.Context, to string, filePath string) error {
+	return c.submit(ctx, EmotionMessage(to, filePath))
+}
@@ -0,0 +1,80 @@
+package wxhelper_sdk
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"wxhelper-sdk/inner"
+)
+
+func TestClient_CheckSend(t *testing.T) {
+	fake := &endpointServer{}
+	client := newTestClient(t, fake)
+	ctx := context.Background()
+
+	// 未登录、已关闭时不发送
+	client.state.Store(int32(StateWaitingLogin))
+	assert.Equal(t, ErrNotLogin, client.SendText(ctx, "wxid_a", "hi"))
+	client.state.Store(int32(StateLoggedOut))
+	assert.Equal(t, ErrNotLogin, client.Forward(ctx, "wxid_a", 1))
+	client.state.Store(int32(StateClosed))
+	assert.Equal(t, ErrClientClosed, client.SendText(ctx, "wxid_a", "hi"))
+	assert.Empty(t, fake.path)
+
+	// 接收者与内容为空
+	client.state.Store(int32(StateLoggedIn))
+	assert.Equal(t, ErrEmptyReceiver, client.SendText(ctx, " ", "hi"))
+	assert.Equal(t, ErrEmptyContent, client.SendText(ctx, "wxid_a", ""))
+	assert.Equal(t, ErrEmptyContent, client.SendImage(ctx, "wxid_a", ""))
+	assert.Equal(t, ErrEmptyContent, client.SendFile(ctx, "wxid_a", ""))
+	assert.Equal(t, ErrEmptyContent, client.SendAtText(ctx, "123@chatroom", []string{"wxid_a"}, ""))
+	assert.Empty(t, fake.path)
+
+	// 关闭中仍可发送
+	client.state.Store(int32(StateClosing))
+	assert.Nil(t, client.SendText(ctx, "wxid_a", "hi"))
+	assert.Equal(t, inner.EndpointSendText, fake.path)
+	assert.Equal(t, map[string]any{"wxid": "wxid_a", "msg": "hi"}, fake.body)
+}
+
+func TestClient_SendError(t *testing.T) {
+	fake := &endpointServer{}
+	client := newTestClient(t, fake, WithRetryPolicy(RetryPolicy{}))
+	ctx := context.Background()
+
+	tests := []struct {
+		op       string
+		to       string
+		endpoint string
+		code     int // 失败的返回码
+		send     func() error
+	}{
+		{"text", "wxid_a", inner.EndpointSendText, -1, func() error { return client.SendText(ctx, "wxid_a", "hi") }},
+		{"image", "wxid_a", inner.EndpointSendImage, 0, func() error { return client.SendImage(ctx, "wxid_a", `C:\a.png`) }},
+		{"file", "wxid_a", inner.EndpointSendFile, 0, func() error { return client.SendFile(ctx, "wxid_a", `C:\a.txt`) }},
+		{"atText", "123@chatroom", inner.EndpointSendAtText, -1, func() error {
+			return client.SendAtText(ctx, "123@chatroom", []string{"wxid_a"}, "hi")
+		}},
+		{"forward", "wxid_b", inner.EndpointForwardMsg, 0, func() error { return client.Forward(ctx, "wxid_b", 123) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.op, func(t *testing.T) {
+			fake.code = tt.code
+			err := tt.send()
+			assert.Equal(t, tt.endpoint, fake.path)
+			var sendErr *SendError
+			if assert.True(t, errors.As(err, &sendErr)) {
+				assert.Equal(t, tt.op, sendErr.Op)
+				assert.Equal(t, tt.to, sendErr.To)
+			}
+			assert.True(t, errors.Is(err, ErrSendFailed))
+			assert.True(t, errors.Is(err, ErrUnexpectedCode))
+			var apiErr *APIError
+			if assert.True(t, errors.As(err, &apiErr)) {
+				assert.Equal(t, tt.endpoint, apiErr.Endpoint)
+				assert.Equal(t, tt.code, apiErr.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,406 @@
+// Package wxhelper_sdk
+// @Author Clover
+// @Data 2025/1/20 上午10:00:00
+// @Desc 发送队列：全局与会话限流、随机间隔、优先级及发送结果
+package wxhelper_sdk
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+var (
+	ErrQueueFull   = errors.New("send queue is full")
+	ErrQueueClosed = errors.New("send queue closed")
+)
+
+// Priority 发送优先级，高优先级的消息先发送
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+	priorityLanes
+)
+
+// SendQueueConfig 发送队列配置，限流速率为 0 表示不限制
+type SendQueueConfig struct {
+	Rate              float64       // 全局每秒发送数
+	Burst             int           // 全局突发数量
+	ConversationRate  float64       // 每个会话每秒发送数
+	ConversationBurst int           // 每个会话突发数量
+	MinDelay          time.Duration // 相邻两条消息之间的最小随机间隔
+	MaxDelay          time.Duration // 相邻两条消息之间的最大随机间隔
+	Size              int           // 队列容量，队列满时 Enqueue 阻塞
+}
+
+var DefaultSendQueueConfig = SendQueueConfig{
+	Rate:              1,
+	Burst:             3,
+	ConversationRate:  0.5,
+	ConversationBurst: 2,
+	MinDelay:          500 * time.Millisecond,
+	MaxDelay:          1500 * time.Millisecond,
+	Size:              256,
+}
+
+// OutboundMessage 待发送的消息，通过 TextMessage 等函数创建
+type OutboundMessage struct {
+	Op       string   // text/image/file/atText/pat/emotion
+	To       string   // 接收者 wxid 或 chatroom id
+	Content  string   // 文本内容，图片、文件、表情在 wxhelper 所在机器上的路径，或被拍的 wxid
+	AtWxids  []string // atText 被@的成员
+	Priority Priority
+}
+
+// TextMessage 文本消息
+func TextMessage(to string, content string) OutboundMessage {
+	return OutboundMessage{Op: "text", To: to, Content: content, Priority: PriorityNormal}
+}
+
+// ImageMessage 图片消息 <imgPath: wxhelper 所在机器上的图片路径>
+func ImageMessage(to string, imgPath string) OutboundMessage {
+	return OutboundMessage{Op: "image", To: to, Content: imgPath, Priority: PriorityNormal}
+}
+
+// FileMessage 文件消息 <filePath: wxhelper 所在机器上的文件路径>
+func FileMessage(to string, filePath string) OutboundMessage {
+	return OutboundMessage{Op: "file", To: to, Content: filePath, Priority: PriorityNormal}
+}
+
+// AtTextMessage 群聊@消息
+func AtTextMessage(chatRoomID string, wxids []string, content string) OutboundMessage {
+	return OutboundMessage{Op: "atText", To: chatRoomID, Content: content, AtWxids: wxids, Priority: PriorityNormal}
+}
+
+// PatMessage 拍一拍 <wxid: 被拍的 wxid>
+func PatMessage(to string, wxid string) OutboundMessage {
+	return OutboundMessage{Op: "pat", To: to, Content: wxid, Priority: PriorityNormal}
+}
+
+// EmotionMessage 表情消息 <filePath: wxhelper 所在机器上的表情文件路径>
+func EmotionMessage(to string, filePath string) OutboundMessage {
+	return OutboundMessage{Op: "emotion", To: to, Content: filePath, Priority: PriorityNormal}
+}
+
+// WithPriority 返回指定优先级的副本
+func (m OutboundMessage) WithPriority(p Priority) OutboundMessage {
+	m.Priority = p
+	return m
+}
+
+// SendResult 消息的发送结果
+type SendResult struct {
+	done   chan struct{}
+	err    error
+	sentAt time.Time
+}
+
+func newSendResult() *SendResult {
+	return &SendResult{done: make(chan struct{})}
+}
+
+func (r *SendResult) complete(err error) {
+	r.err, r.sentAt = err, time.Now()
+	close(r.done)
+}
+
+// Done 发送完成（成功或失败）时关闭
+func (r *SendResult) Done() <-chan struct{} {
+	return r.done
+}
+
+// Wait 等待发送完成并返回发送错误，ctx 结束时返回 ctx.Err()（消息仍在队列中）
+func (r *SendResult) Wait(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-r.done:
+		return r.err
+	}
+}
+
+// Err 发送错误，需在 Done 之后调用
+func (r *SendResult) Err() error {
+	return r.err
+}
+
+// SentAt 发送完成的时间，需在 Done 之后调用
+func (r *SendResult) SentAt() time.Time {
+	return r.sentAt
+}
+
+type sendJob struct {
+	ctx    context.Context
+	msg    OutboundMessage
+	result *SendResult
+}
+
+// SendQueue 发送队列，由单个 worker 按优先级依次发送，发送前等待全局、会话令牌及随机间隔
+type SendQueue struct {
+	client *Client
+	cfg    SendQueueConfig
+	global *tokenBucket  // 为空时不限制
+	perTo  *keyedLimiter // 为空时不限制
+
+	mu       sync.Mutex
+	lanes    [priorityLanes][]*sendJob
+	size     int
+	inflight bool // worker 已取出、尚未完成的消息
+	closed   bool
+	notEmpty chan struct{} // 有新消息时通知 worker
+	space    chan struct{} // 有空位时关闭并替换，唤醒所有等待的 Enqueue
+
+	stop     context.CancelFunc
+	ctx      context.Context
+	finished chan struct{}
+	lastSent time.Time
+}
+
+// NewSendQueue 创建发送队列并启动 worker，客户端关闭时队列随之停止
+func NewSendQueue(client *Client, cfg SendQueueConfig) *SendQueue {
+	if cfg.Size < 1 {
+		cfg.Size = DefaultSendQueueConfig.Size
+	}
+	if cfg.MaxDelay < cfg.MinDelay {
+		cfg.MaxDelay = cfg.MinDelay
+	}
+	ctx, cancel := context.WithCancel(client.ctx)
+	q := &SendQueue{
+		client:   client,
+		cfg:      cfg,
+		notEmpty: make(chan struct{}, 1),
+		space:    make(chan struct{}),
+		ctx:      ctx,
+		stop:     cancel,
+		finished: make(chan struct{}),
+	}
+	if cfg.Rate > 0 {
+		q.global = newTokenBucket(cfg.Rate, cfg.Burst)
+	}
+	if cfg.ConversationRate > 0 {
+		q.perTo = newKeyedLimiter(cfg.ConversationRate, cfg.ConversationBurst)
+	}
+	go q.run()
+	return q
+}
+
+// Enqueue 将消息加入队列，队列满时阻塞直到有空位或 ctx 结束
+// ctx 同时作用于发送本身，消息发送前 ctx 已结束则以 ctx.Err() 完成
+func (q *SendQueue) Enqueue(ctx context.Context, msg OutboundMessage) (*SendResult, error) {
+	for {
+		result, space, err := q.tryEnqueue(ctx, msg)
+		if !errors.Is(err, ErrQueueFull) {
+			return result, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-q.ctx.Done():
+			return nil, ErrQueueClosed
+		case <-space:
+		}
+	}
+}
+
+// TryEnqueue 将消息加入队列，队列满时立即返回 ErrQueueFull
+func (q *SendQueue) TryEnqueue(ctx context.Context, msg OutboundMessage) (*SendResult, error) {
+	result, _, err := q.tryEnqueue(ctx, msg)
+	return result, err
+}
+
+func (q *SendQueue) tryEnqueue(ctx context.Context, msg OutboundMessage) (*SendResult, <-chan struct{}, error) {
+	if msg.Priority < PriorityLow || msg.Priority >= priorityLanes {
+		msg.Priority = PriorityNormal
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed || q.ctx.Err() != nil {
+		return nil, nil, ErrQueueClosed
+	}
+	if q.size >= q.cfg.Size {
+		return nil, q.space, ErrQueueFull
+	}
+	job := &sendJob{ctx: ctx, msg: msg, result: newSendResult()}
+	q.lanes[msg.Priority] = append(q.lanes[msg.Priority], job)
+	q.size++
+	select {
+	case q.notEmpty <- struct{}{}:
+	default:
+	}
+	return job.result, nil, nil
+}
+
+// Len 队列中等待发送的消息数
+func (q *SendQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.size
+}
+
+// pending 等待发送及正在发送的消息数
+func (q *SendQueue) pending() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.inflight {
+		return q.size + 1
+	}
+	return q.size
+}
+
+// next 取出优先级最高且所在会话有令牌的消息，均无令牌时返回最短等待时长
+func (q *SendQueue) next() (*sendJob, time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	wait := time.Duration(-1)
+	for lane := priorityLanes - 1; lane >= PriorityLow; lane-- {
+		for i, job := range q.lanes[lane] {
+			var d time.Duration
+			if q.perTo != nil {
+				d = q.perTo.get(job.msg.To).delay()
+			}
+			if d > 0 {
+				if wait < 0 || d < wait {
+					wait = d
+				}
+				continue
+			}
+			q.lanes[lane] = append(q.lanes[lane][:i:i], q.lanes[lane][i+1:]...)
+			q.size--
+			q.inflight = true
+			close(q.space)
+			q.space = make(chan struct{})
+			return job, 0
+		}
+	}
+	return nil, wait
+}
+
+func (q *SendQueue) run() {
+	defer close(q.finished)
+	defer q.failPending(ErrQueueClosed)
+	for {
+		job, wait := q.next()
+		if job == nil {
+			var timer <-chan time.Time
+			if wait >= 0 {
+				timer = time.After(wait)
+			}
+			select {
+			case <-q.ctx.Done():
+				return
+			case <-q.notEmpty:
+			case <-timer:
+			}
+			continue
+		}
+		if !q.pace(job) {
+			job.result.complete(ErrQueueClosed)
+			return
+		}
+		if err := job.ctx.Err(); err != nil {
+			job.result.complete(err) // 已取消的消息不发送，也不影响发送间隔
+		} else {
+			job.result.complete(q.send(job))
+			q.lastSent = time.Now()
+		}
+		q.mu.Lock()
+		q.inflight = false
+		q.mu.Unlock()
+	}
+}
+
+// pace 发送前等待全局令牌与随机间隔，队列停止时返回 false
+// 消息的 ctx 结束时立即返回，已取消的消息不占用全局及会话令牌
+func (q *SendQueue) pace(job *sendJob) bool {
+	var ready time.Time // 随机间隔结束的时间
+	if !q.lastSent.IsZero() && q.cfg.MaxDelay > 0 {
+		delay := q.cfg.MinDelay
+		if span := q.cfg.MaxDelay - q.cfg.MinDelay; span > 0 {
+			delay += rand.N(span)
+		}
+		ready = q.lastSent.Add(delay)
+	}
+	for {
+		if job.ctx.Err() != nil {
+			return true
+		}
+		wait := time.Until(ready)
+		if q.global != nil {
+			wait = max(wait, q.global.delay())
+		}
+		if wait <= 0 {
+			break
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-q.ctx.Done():
+			timer.Stop()
+			return false
+		case <-job.ctx.Done():
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+	if q.global != nil {
+		q.global.reserve()
+	}
+	if q.perTo != nil {
+		q.perTo.reserve(job.msg.To)
+	}
+	return true
+}
+
+func (q *SendQueue) send(job *sendJob) error {
+	err := q.client.checkMessage(job.msg)
+	if err == nil {
+		err = q.client.deliver(job.ctx, job.msg)
+	}
+	if err != nil {
+		q.client.log.WarnWithErr(err, "queued send failed", map[string]interface{}{"op": job.msg.Op, "to": job.msg.To})
+	}
+	return err
+}
+
+// failPending 以 err 完成所有未发送的消息
+func (q *SendQueue) failPending(err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	for lane := range q.lanes {
+		for _, job := range q.lanes[lane] {
+			job.result.complete(err)
+		}
+		q.lanes[lane] = nil
+	}
+	q.size = 0
+	close(q.space)
+	q.space = make(chan struct{})
+}
+
+// Close 停止接收新消息并等待队列发送完毕，ctx 结束时未发送的消息以 ErrQueueClosed 完成
+func (q *SendQueue) Close(ctx context.Context) error {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for q.pending() > 0 {
+		select {
+		case <-ctx.Done():
+			q.stop()
+			<-q.finished
+			return ctx.Err()
+		case <-q.finished:
+			return nil
+		case <-ticker.C:
+		}
+	}
+	q.stop()
+	<-q.finished
+	return nil
+}
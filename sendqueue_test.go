@@ -0,0 +1,214 @@
+package wxhelper_sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSender 记录 /api/sendTextMsg 的请求，gate 非空时每个请求等待放行
+type fakeSender struct {
+	mu    sync.Mutex
+	sent  []string
+	times []time.Time
+	gate  chan struct{}
+}
+
+func (f *fakeSender) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]string
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	if f.gate != nil {
+		<-f.gate
+	}
+	f.mu.Lock()
+	f.sent = append(f.sent, body["msg"])
+	f.times = append(f.times, time.Now())
+	f.mu.Unlock()
+	code := 0
+	if body["msg"] == "fail" {
+		code = -1
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"code": code, "msg": "success"})
+}
+
+func (f *fakeSender) messages() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.sent...)
+}
+
+func TestSendQueue_Priority(t *testing.T) {
+	fake := &fakeSender{gate: make(chan struct{})}
+	queue := NewSendQueue(newTestClient(t, fake), SendQueueConfig{Size: 10})
+	ctx := context.Background()
+
+	first, err := queue.Enqueue(ctx, TextMessage("wxid_a", "a").WithPriority(PriorityLow))
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool { return queue.Len() == 0 }, time.Second, 5*time.Millisecond) // a 正在发送
+	var results []*SendResult
+	for _, msg := range []OutboundMessage{
+		TextMessage("wxid_a", "b").WithPriority(PriorityLow),
+		TextMessage("wxid_a", "c").WithPriority(PriorityHigh),
+		TextMessage("wxid_a", "d"),
+	} {
+		result, err := queue.Enqueue(ctx, msg)
+		assert.Nil(t, err)
+		results = append(results, result)
+	}
+	close(fake.gate)
+	assert.Nil(t, first.Wait(ctx))
+	for _, result := range results {
+		assert.Nil(t, result.Wait(ctx))
+	}
+	assert.Equal(t, []string{"a", "c", "d", "b"}, fake.messages())
+}
+
+func TestSendQueue_ConversationRate(t *testing.T) {
+	fake := &fakeSender{}
+	queue := NewSendQueue(newTestClient(t, fake), SendQueueConfig{ConversationRate: 10, ConversationBurst: 1, Size: 10})
+	ctx := context.Background()
+
+	var results []*SendResult
+	for _, msg := range []OutboundMessage{TextMessage("wxid_x", "x1"), TextMessage("wxid_x", "x2"), TextMessage("wxid_y", "y1")} {
+		result, err := queue.Enqueue(ctx, msg)
+		assert.Nil(t, err)
+		results = append(results, result)
+	}
+	for _, result := range results {
+		assert.Nil(t, result.Wait(ctx))
+	}
+	// x2 等待会话令牌时不阻塞 y1
+	assert.Equal(t, []string{"x1", "y1", "x2"}, fake.messages())
+	assert.GreaterOrEqual(t, results[1].SentAt().Sub(results[0].SentAt()), 80*time.Millisecond)
+}
+
+func TestSendQueue_Delay(t *testing.T) {
+	fake := &fakeSender{}
+	queue := NewSendQueue(newTestClient(t, fake), SendQueueConfig{MinDelay: 50 * time.Millisecond, MaxDelay: 80 * time.Millisecond})
+	ctx := context.Background()
+
+	first, _ := queue.Enqueue(ctx, TextMessage("wxid_a", "1"))
+	second, _ := queue.Enqueue(ctx, TextMessage("wxid_b", "2"))
+	assert.Nil(t, second.Wait(ctx))
+	assert.Nil(t, first.Err())
+	gap := fake.times[1].Sub(fake.times[0])
+	assert.GreaterOrEqual(t, gap, 50*time.Millisecond)
+	assert.Less(t, gap, 200*time.Millisecond)
+}
+
+func TestSendQueue_BackPressure(t *testing.T) {
+	fake := &fakeSender{gate: make(chan struct{})}
+	queue := NewSendQueue(newTestClient(t, fake), SendQueueConfig{Size: 1})
+	ctx := context.Background()
+
+	_, err := queue.Enqueue(ctx, TextMessage("wxid_a", "a"))
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool { return queue.Len() == 0 }, time.Second, 5*time.Millisecond)
+	_, err = queue.Enqueue(ctx, TextMessage("wxid_a", "b"))
+	assert.Nil(t, err)
+
+	_, err = queue.TryEnqueue(ctx, TextMessage("wxid_a", "c"))
+	assert.Equal(t, ErrQueueFull, err)
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = queue.Enqueue(timeoutCtx, TextMessage("wxid_a", "c"))
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	// 有空位后阻塞的 Enqueue 继续
+	enqueued := make(chan error, 1)
+	go func() {
+		_, err := queue.Enqueue(ctx, TextMessage("wxid_a", "c"))
+		enqueued <- err
+	}()
+	close(fake.gate)
+	assert.Nil(t, <-enqueued)
+	assert.Nil(t, queue.Close(ctx))
+	assert.Equal(t, []string{"a", "b", "c"}, fake.messages())
+}
+
+func TestSendQueue_Result(t *testing.T) {
+	fake := &fakeSender{}
+	queue := NewSendQueue(newTestClient(t, fake), SendQueueConfig{})
+	ctx := context.Background()
+
+	result, err := queue.Enqueue(ctx, TextMessage("wxid_a", "fail"))
+	assert.Nil(t, err)
+	err = result.Wait(ctx)
+	var sendErr *SendError
+	if assert.True(t, errors.As(err, &sendErr)) {
+		assert.Equal(t, "wxid_a", sendErr.To)
+	}
+	assert.True(t, errors.Is(err, ErrUnexpectedCode))
+
+	// 关闭后不再接收
+	assert.Nil(t, queue.Close(ctx))
+	_, err = queue.Enqueue(ctx, TextMessage("wxid_a", "late"))
+	assert.Equal(t, ErrQueueClosed, err)
+}
+
+func TestSendQueue_CloseTimeout(t *testing.T) {
+	fake := &fakeSender{}
+	queue := NewSendQueue(newTestClient(t, fake), SendQueueConfig{Rate: 1, Burst: 1, Size: 10})
+	ctx := context.Background()
+
+	first, _ := queue.Enqueue(ctx, TextMessage("wxid_a", "1"))
+	second, _ := queue.Enqueue(ctx, TextMessage("wxid_a", "2"))
+	assert.Nil(t, first.Wait(ctx))
+	closeCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, queue.Close(closeCtx))
+	assert.Equal(t, ErrQueueClosed, second.Wait(ctx))
+}
+
+func TestSendQueue_CancelledSkipsPacing(t *testing.T) {
+	fake := &fakeSender{}
+	queue := NewSendQueue(newTestClient(t, fake), SendQueueConfig{Rate: 10, Burst: 1, ConversationRate: 10, ConversationBurst: 1, Size: 10})
+	ctx := context.Background()
+
+	first, _ := queue.Enqueue(ctx, TextMessage("wxid_a", "1"))
+	assert.Nil(t, first.Wait(ctx))
+	cancelCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	second, _ := queue.Enqueue(cancelCtx, TextMessage("wxid_b", "2"))
+	third, _ := queue.Enqueue(ctx, TextMessage("wxid_b", "3"))
+	assert.Equal(t, context.DeadlineExceeded, second.Wait(ctx))
+	assert.Nil(t, third.Wait(ctx))
+
+	// 已取消的消息不占用全局及会话令牌
+	assert.Equal(t, []string{"1", "3"}, fake.messages())
+	gap := third.SentAt().Sub(first.SentAt())
+	assert.GreaterOrEqual(t, gap, 80*time.Millisecond)
+	assert.Less(t, gap, 180*time.Millisecond)
+}
+
+func TestClient_WithSendQueue(t *testing.T) {
+	fake := &fakeSender{gate: make(chan struct{})}
+	client := newTestClient(t, fake, WithSendQueue(SendQueueConfig{Size: 10}))
+	ctx := context.Background()
+
+	// SendText 与 Reply 经由发送队列，阻塞直到发出
+	sent := make(chan error, 2)
+	go func() { sent <- client.SendText(ctx, "wxid_a", "a") }()
+	assert.Eventually(t, func() bool { return client.queue.pending() == 1 }, time.Second, 5*time.Millisecond)
+	msg := &Message{Type: MsgTypeText, FromUser: "wxid_b", Content: "hi", client: client}
+	go func() { sent <- msg.Reply(ctx, "b") }()
+	assert.Eventually(t, func() bool { return client.queue.pending() == 2 }, time.Second, 5*time.Millisecond)
+	close(fake.gate)
+	assert.Nil(t, <-sent)
+	assert.Nil(t, <-sent)
+	assert.Equal(t, []string{"a", "b"}, fake.messages())
+
+	// 校验失败的消息不进入队列
+	assert.Equal(t, ErrEmptyContent, client.SendText(ctx, "wxid_a", ""))
+	assert.Equal(t, 0, client.queue.pending())
+
+	// 关闭客户端时队列随之关闭
+	assert.Nil(t, client.Close(ctx))
+	_, err := client.queue.Enqueue(ctx, TextMessage("wxid_a", "late"))
+	assert.Equal(t, ErrQueueClosed, err)
+}